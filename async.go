@@ -0,0 +1,42 @@
+package highCL
+
+import (
+	"errors"
+)
+
+// BuildResult result of asynchronous build
+type BuildResult struct {
+	Program *Program
+	Err     error
+}
+
+// AddProgramAsync compiles programs sources with build options in a new goroutine,
+// the returned channel yields one result and is closed then.
+// Several programs can be built concurrently, the program cache and the build log hook set at the call are used,
+// the hook is called from the building goroutine
+func (d *Device) AddProgramAsync(sources []string, opts *BuildOptions) <-chan BuildResult {
+	res := make(chan BuildResult, 1)
+	cache, hook := d.buildSettings()
+	flags := opts.String()
+	go func() {
+		defer close(res)
+		p, err := d.addProgram(sources, flags, cache, hook)
+		res <- BuildResult{Program: p, Err: err}
+	}()
+	return res
+}
+
+// WaitPrograms waits for all results of AddProgramAsync, it returns built programs in the order of results
+// and errors of failed builds joined by errors.Join, errors.As finds *BuildError of any of them
+func WaitPrograms(results ...<-chan BuildResult) ([]*Program, error) {
+	programs := make([]*Program, len(results))
+	var errs []error
+	for i, r := range results {
+		built := <-r
+		programs[i] = built.Program
+		if built.Err != nil {
+			errs = append(errs, built.Err)
+		}
+	}
+	return programs, errors.Join(errs...)
+}
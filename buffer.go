@@ -17,6 +17,9 @@ type buffer struct {
 
 // newBuffer creates new buffer with specified size
 func newBuffer(d *Device, size int) (*buffer, error) {
+	if err := require("clCreateBuffer"); err != nil {
+		return nil, err
+	}
 	var ret pure.Status
 	clBuffer := pure.CreateBuffer(d.ctx, constants.CL_MEM_READ_WRITE, pure.Size(size), nil, &ret)
 	if err := pure.StatusToErr(ret); err != nil {
@@ -34,18 +37,29 @@ func newBuffer(d *Device, size int) (*buffer, error) {
 
 // Release releases the buffer on the device
 func (b *buffer) Release() error {
+	if err := require("clReleaseMemObject"); err != nil {
+		return err
+	}
 	return pure.StatusToErr(pure.ReleaseMemObject(b.memobj))
 }
 
 func (b *buffer) copy(size int, ptr unsafe.Pointer) <-chan error {
+	return b.copyOn(b.device.queue, size, ptr)
+}
+
+func (b *buffer) copyOn(queue pure.CommandQueue, size int, ptr unsafe.Pointer) <-chan error {
 	ch := make(chan error, 1)
+	if err := require("clEnqueueWriteBuffer", "clWaitForEvents", "clReleaseEvent"); err != nil {
+		ch <- err
+		return ch
+	}
 	if b.size != pure.Size(size) {
 		ch <- errors.New("buffer size not equal to data len")
 		return ch
 	}
 	var event pure.Event
 	err := pure.StatusToErr(pure.EnqueueWriteBuffer(
-		b.device.queue,
+		queue,
 		b.memobj,
 		false,
 		0,
@@ -70,3 +84,21 @@ func (b *buffer) copy(size int, ptr unsafe.Pointer) <-chan error {
 	}()
 	return ch
 }
+
+// read reads whole buffer to ptr, it's a blocking call
+func (b *buffer) read(queue pure.CommandQueue, ptr unsafe.Pointer) error {
+	if err := require("clEnqueueReadBuffer"); err != nil {
+		return err
+	}
+	return pure.StatusToErr(pure.EnqueueReadBuffer(
+		queue,
+		b.memobj,
+		true,
+		0,
+		b.size,
+		ptr,
+		0,
+		nil,
+		nil,
+	))
+}
@@ -0,0 +1,103 @@
+package highCL
+
+import (
+	"fmt"
+	pure "github.com/opencl-pure/pureCL"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic one compiler message parsed from the build log
+type Diagnostic struct {
+	File     string // e.g. "<kernel>" or "<source>" for sources given as strings
+	Line     int
+	Column   int    // zero when the compiler does not report it
+	Severity string // "error", "fatal error", "warning", "note" or "remark"
+	Message  string
+}
+
+// String compiler-style diagnostic, file:line:column: severity: message
+func (d Diagnostic) String() string {
+	if d.Column == 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// DeviceBuildLog build log of one device of the program
+type DeviceBuildLog struct {
+	Device      string // name of the device
+	Log         string
+	Diagnostics []Diagnostic
+}
+
+// BuildError program build, compile or link failed, match it by errors.As
+type BuildError struct {
+	Status  pure.Status
+	Options string
+	Logs    []DeviceBuildLog
+}
+
+func (e *BuildError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("cl: build failed: " + e.Unwrap().Error())
+	if e.Options != "" {
+		sb.WriteString(" (options " + strconv.Quote(e.Options) + ")")
+	}
+	for _, l := range e.Logs {
+		if l.Log == "" {
+			continue
+		}
+		sb.WriteString("\n" + l.Device + ":\n" + l.Log)
+	}
+	return sb.String()
+}
+
+// Unwrap the error of the status
+func (e *BuildError) Unwrap() error {
+	return pure.StatusToErr(e.Status)
+}
+
+// Diagnostics diagnostics of all devices
+func (e *BuildError) Diagnostics() []Diagnostic {
+	var res []Diagnostic
+	for _, l := range e.Logs {
+		res = append(res, l.Diagnostics...)
+	}
+	return res
+}
+
+// newBuildError collects build logs of all devices of the failed program,
+// device whose name can not be read is described by the error of the query
+func newBuildError(p pure.Program, devices []pure.Device, status pure.Status, options string) *BuildError {
+	e := &BuildError{Status: status, Options: strings.TrimSpace(options)}
+	for _, id := range devices {
+		name, err := deviceName(id)
+		if err != nil {
+			name = "unknown device (" + err.Error() + ")"
+		}
+		log := buildLog(p, id)
+		e.Logs = append(e.Logs, DeviceBuildLog{Device: name, Log: log, Diagnostics: ParseDiagnostics(log)})
+	}
+	return e
+}
+
+var diagnosticRegexp = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s*(fatal error|error|warning|note|remark)\s*:\s*(.*)$`)
+
+// ParseDiagnostics parses clang-style lines (file:line:column: severity: message) of the build log,
+// other lines (source excerpts, carets) are skipped
+func ParseDiagnostics(log string) []Diagnostic {
+	var res []Diagnostic
+	for _, line := range strings.Split(log, "\n") {
+		m := diagnosticRegexp.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		d := Diagnostic{File: m[1], Severity: m[4], Message: m[5]}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		res = append(res, d)
+	}
+	return res
+}
@@ -1,66 +1,195 @@
 package highCL
 
 import (
+	"fmt"
 	pure "github.com/opencl-pure/pureCL"
+	"sort"
 	"strings"
 )
 
 type BuildOptions struct {
 	// Preprocessor options
-	Warnings          bool
-	Macros            map[string]string
-	DirectoryIncludes []string
+	Warnings          bool              // -w inhibits all warnings
+	WarningsAsErrors  bool              // -Werror
+	Macros            map[string]string // -D name=value, empty value defines name as 1
+	DirectoryIncludes []string          // -I dir
 	Version           pure.Version
+	// Optimization and debugging options
+	OptDisable bool // -cl-opt-disable
+	Debug      bool // -g
 	// Math intrinsics options
 	SinglePrecisionConstant bool
+	DenormsAreZero          bool
 	MadEnable               bool
 	NoSignedZeros           bool
+	FiniteMathOnly          bool
 	FastRelaxedMaths        bool
 	UnsafeMaths             bool
+	// Kernel argument info is kept for Kernel arguments queries (-cl-kernel-arg-info)
+	KernelArgInfo bool
 	// Extensions
 	NvidiaVerbose bool
 }
 
+// flags all boolean options with their compiler flag, in the order of String
+func (op *BuildOptions) flags() []struct {
+	flag  string
+	value *bool
+} {
+	return []struct {
+		flag  string
+		value *bool
+	}{
+		{"-w", &op.Warnings},
+		{"-Werror", &op.WarningsAsErrors},
+		{"-cl-opt-disable", &op.OptDisable},
+		{"-g", &op.Debug},
+		{"-cl-single-precision-constant", &op.SinglePrecisionConstant},
+		{"-cl-denorms-are-zero", &op.DenormsAreZero},
+		{"-cl-mad-enable", &op.MadEnable},
+		{"-cl-no-signed-zeros", &op.NoSignedZeros},
+		{"-cl-finite-math-only", &op.FiniteMathOnly},
+		{"-cl-fast-relaxed-math", &op.FastRelaxedMaths},
+		{"-cl-unsafe-math-optimizations", &op.UnsafeMaths},
+		{"-cl-kernel-arg-info", &op.KernelArgInfo},
+		{"-cl-nv-verbose", &op.NvidiaVerbose},
+	}
+}
+
 func (op *BuildOptions) String() string {
 	if op == nil {
 		return ""
 	}
 	var sb strings.Builder
 	// Preprocessor
-	if op.Warnings {
-		sb.WriteString("-w")
-		sb.WriteRune(' ')
+	names := make([]string, 0, len(op.Macros))
+	for name := range op.Macros {
+		names = append(names, name)
 	}
-	if op.Version != "" {
-		sb.WriteString("-cl-std=" + string(op.Version))
+	sort.Strings(names)
+	for _, name := range names {
+		sb.WriteString("-D ")
+		if value := op.Macros[name]; value != "" {
+			sb.WriteString(quoteBuildOption(name + "=" + value))
+		} else {
+			sb.WriteString(quoteBuildOption(name))
+		}
 		sb.WriteRune(' ')
 	}
-	// Math intrinsics
-	if op.SinglePrecisionConstant {
-		sb.WriteString("-cl-single-precision-constant")
+	for _, dir := range op.DirectoryIncludes {
+		sb.WriteString("-I " + quoteBuildOption(dir))
 		sb.WriteRune(' ')
 	}
-	if op.MadEnable {
-		sb.WriteString("-cl-mad-enable")
+	if op.Version != "" {
+		sb.WriteString("-cl-std=" + string(op.Version))
 		sb.WriteRune(' ')
 	}
-	if op.NoSignedZeros {
-		sb.WriteString("-cl-no-signed-zeros")
-		sb.WriteRune(' ')
+	// Warnings, optimization, debugging, math intrinsics and extensions
+	for _, f := range op.flags() {
+		if *f.value {
+			sb.WriteString(f.flag)
+			sb.WriteRune(' ')
+		}
 	}
-	if op.FastRelaxedMaths {
-		sb.WriteString("-cl-fast-relaxed-math")
-		sb.WriteRune(' ')
+	return sb.String()
+}
+
+// ParseBuildOptions parses compiler flags (e.g. built by BuildOptions.String) back into BuildOptions,
+// it returns error for flags BuildOptions can not hold
+func ParseBuildOptions(flags string) (*BuildOptions, error) {
+	tokens, err := splitBuildOptions(flags)
+	if err != nil {
+		return nil, err
 	}
-	if op.UnsafeMaths {
-		sb.WriteString("-cl-unsafe-math-optimizations")
-		sb.WriteRune(' ')
+	op := &BuildOptions{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case strings.HasPrefix(token, "-D") || strings.HasPrefix(token, "-I"):
+			value := token[2:]
+			if value == "" {
+				i++
+				if i == len(tokens) {
+					return nil, fmt.Errorf("cl: build option %s needs a value", token)
+				}
+				value = tokens[i]
+			}
+			if token[1] == 'I' {
+				op.DirectoryIncludes = append(op.DirectoryIncludes, value)
+				continue
+			}
+			if op.Macros == nil {
+				op.Macros = map[string]string{}
+			}
+			name, macro, _ := strings.Cut(value, "=")
+			op.Macros[name] = macro
+		case strings.HasPrefix(token, "-cl-std="):
+			op.Version = pure.Version(strings.TrimPrefix(token, "-cl-std="))
+		default:
+			known := false
+			for _, f := range op.flags() {
+				if f.flag == token {
+					*f.value = true
+					known = true
+					break
+				}
+			}
+			if !known {
+				return nil, fmt.Errorf("cl: unknown build option %q", token)
+			}
+		}
 	}
-	// Extensions
-	if op.NvidiaVerbose {
-		sb.WriteString("-cl-nv-verbose")
-		sb.WriteRune(' ')
+	return op, nil
+}
+
+// quoteBuildOption quotes value containing spaces or quotes
+func quoteBuildOption(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'\\") {
+		return value
 	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
 
-	return sb.String()
+// splitBuildOptions splits flags by white space, respecting quotes and backslash escapes
+func splitBuildOptions(flags string) ([]string, error) {
+	var tokens []string
+	var sb strings.Builder
+	inToken := false
+	var quote rune
+	escaped := false
+	for _, r := range flags {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inToken {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+				inToken = false
+			}
+		default:
+			sb.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("cl: unterminated quote in build options %q", flags)
+	}
+	if inToken {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens, nil
 }
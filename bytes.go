@@ -35,29 +35,34 @@ func (b *Bytes) Set(data []byte) <-chan error {
 	return b.buf.copy(len(data), unsafe.Pointer(&data[0]))
 }
 
+// SetOn copies the data from host data to device buffer using the queue
+// it's a non-blocking call, channel will return an error or nil if the data transfer is complete
+func (b *Bytes) SetOn(q *Queue, data []byte) <-chan error {
+	return b.buf.copyOn(q.queue, len(data), unsafe.Pointer(&data[0]))
+}
+
 // Data gets data from device, it's a blocking call
 func (b *Bytes) Data() ([]byte, error) {
+	return b.data(b.buf.device.queue)
+}
+
+// DataOn gets data from device using the queue, it's a blocking call
+func (b *Bytes) DataOn(q *Queue) ([]byte, error) {
+	return b.data(q.queue)
+}
+
+func (b *Bytes) data(queue pure.CommandQueue) ([]byte, error) {
 	data := make([]byte, b.buf.size)
-	err := pure.StatusToErr(pure.EnqueueReadBuffer(
-		b.buf.device.queue,
-		b.buf.memobj,
-		true,
-		0,
-		b.buf.size,
-		unsafe.Pointer(&data[0]),
-		0,
-		nil,
-		nil,
-	))
+	err := b.buf.read(queue, unsafe.Pointer(&data[0]))
 	if err != nil {
 		return nil, err
 	}
 	return data, nil
 }
 
-// Map applies an map kernel on all elements of the buffer
+// Map applies an map kernel on all elements of the buffer, local work sizes are chosen by KernelCall.AutoLocal
 // It's a non-blocking call, so it can return an event object that you can wait on.
 // The caller is responsible to release the returned event when it's not used anymore.
 func (b *Bytes) Map(k *Kernel, waitEvents []*Event) (*Event, error) {
-	return k.Global(int(b.buf.size)).Local(1).Run(waitEvents, b)
+	return k.Global(int(b.buf.size)).AutoLocal().Run(waitEvents, b)
 }
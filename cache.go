@@ -0,0 +1,248 @@
+package highCL
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"unsafe"
+)
+
+// ProgramCache on-disk cache of program binaries,
+// programs built with the cache are stored after the first source build and later loaded from the binaries.
+// The key is a hash of sources, flags, device names and driver versions,
+// binaries rejected by the driver fall back to the source build which replaces them
+type ProgramCache struct {
+	dir string
+}
+
+// NewProgramCache opens cache in directory dir, the directory is created when missing
+func NewProgramCache(dir string) (*ProgramCache, error) {
+	if dir == "" {
+		return nil, errors.New("cl: program cache needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ProgramCache{dir: dir}, nil
+}
+
+// Dir directory of the cache
+func (c *ProgramCache) Dir() string {
+	return c.dir
+}
+
+// Clear removes all cached binaries
+func (c *ProgramCache) Clear() error {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.bin"))
+	if err != nil {
+		return err
+	}
+	var result error
+	for _, f := range files {
+		result = pure.ErrJoin(result, os.Remove(f))
+	}
+	return result
+}
+
+// SetProgramCache enables the cache for programs added to the device, nil disables it
+func (d *Device) SetProgramCache(cache *ProgramCache) {
+	d.mu.Lock()
+	d.cache = cache
+	d.mu.Unlock()
+}
+
+// SetProgramCache enables the cache for programs added to the context and to its devices, nil disables it
+func (c *Context) SetProgramCache(cache *ProgramCache) {
+	c.mu.Lock()
+	c.cache = cache
+	c.mu.Unlock()
+	for _, d := range c.devices {
+		d.SetProgramCache(cache)
+	}
+}
+
+// buildProgramCached builds program by buildProgram, or loads it from cache when the cache is not nil
+func buildProgramCached(cache *ProgramCache, ctx pure.Context, devices []pure.Device, sources []string, flags string) (pure.Program, error) {
+	if cache == nil {
+		return buildProgram(ctx, devices, sources, flags)
+	}
+	key, keyErr := cache.deviceKey(devices, sources, flags)
+	if keyErr == nil {
+		if p, ok := cache.load(ctx, devices, key, flags); ok {
+			return p, nil
+		}
+	}
+	p, err := buildProgram(ctx, devices, sources, flags)
+	if err != nil {
+		return 0, err
+	}
+	if keyErr == nil {
+		if err = cache.store(p, key, devices); err != nil {
+			log.Println(err)
+		}
+	}
+	return p, nil
+}
+
+// deviceKey cache key of the program built for devices
+func (c *ProgramCache) deviceKey(devices []pure.Device, sources []string, flags string) (string, error) {
+	ids := make([]string, len(devices))
+	for i, id := range devices {
+		name, err := deviceName(id)
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			return "", errors.New("cl: program cache needs the device name")
+		}
+		driver, err := deviceString(id, constants.CL_DRIVER_VERSION)
+		if err != nil {
+			return "", err
+		}
+		ids[i] = name + "\x00" + driver
+	}
+	return programCacheKey(sources, flags, ids), nil
+}
+
+// programCacheKey hex sha256 of length prefixed sources, flags and device identifications
+func programCacheKey(sources []string, flags string, devices []string) string {
+	h := sha256.New()
+	write := func(s string) {
+		_ = binary.Write(h, binary.LittleEndian, uint64(len(s)))
+		h.Write([]byte(s))
+	}
+	write("highCL program cache v1")
+	_ = binary.Write(h, binary.LittleEndian, uint64(len(sources)))
+	for _, s := range sources {
+		write(s)
+	}
+	write(flags)
+	for _, d := range devices {
+		write(d)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ProgramCache) path(key string, i int) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%d.bin", key, i))
+}
+
+// load creates and builds program from cached binaries, ok is false on a miss or when the driver rejects them
+func (c *ProgramCache) load(ctx pure.Context, devices []pure.Device, key, flags string) (p pure.Program, ok bool) {
+	binaries := make([][]byte, len(devices))
+	for i := range devices {
+		b, err := os.ReadFile(c.path(key, i))
+		if err != nil || len(b) == 0 {
+			return 0, false
+		}
+		binaries[i] = b
+	}
+	p, _, err := createProgramFromBinaries(ctx, devices, binaries)
+	if err != nil {
+		return 0, false
+	}
+	if err = buildCreatedProgram(p, devices, flags); err != nil {
+		return 0, false
+	}
+	return p, true
+}
+
+// store writes binaries of program p for devices under key, every file is written to a temporary file and renamed.
+// Program created in a shared context is associated with all context devices, only binaries of devices are stored
+func (c *ProgramCache) store(p pure.Program, key string, devices []pure.Device) error {
+	prog := &Program{program: p}
+	all, err := prog.GetBinaries()
+	if err != nil {
+		return err
+	}
+	programDevices, err := prog.devices()
+	if err != nil {
+		return err
+	}
+	binaries, err := binariesForDevices(programDevices, all, devices)
+	if err != nil {
+		return err
+	}
+	for i, b := range binaries {
+		if len(b) == 0 {
+			return errors.New("cl: program cache got empty binary")
+		}
+		tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(b)
+		err = pure.ErrJoin(err, tmp.Close())
+		if err == nil {
+			err = os.Rename(tmp.Name(), c.path(key, i))
+		}
+		if err != nil {
+			return pure.ErrJoin(err, os.Remove(tmp.Name()))
+		}
+	}
+	return nil
+}
+
+// binariesForDevices picks binaries of devices from binaries of all program devices (in CL_PROGRAM_DEVICES order)
+func binariesForDevices(programDevices []pure.Device, binaries [][]byte, devices []pure.Device) ([][]byte, error) {
+	if len(binaries) != len(programDevices) {
+		return nil, fmt.Errorf("cl: program has %d devices, got %d binaries", len(programDevices), len(binaries))
+	}
+	res := make([][]byte, len(devices))
+	for i, id := range devices {
+		found := false
+		for j, programDevice := range programDevices {
+			if programDevice == id {
+				res[i], found = binaries[j], true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("cl: program is not associated with the device")
+		}
+	}
+	return res, nil
+}
+
+// createProgramFromBinaries creates program from one binary per device,
+// it returns status of every binary, the program is released when any of them fails
+func createProgramFromBinaries(ctx pure.Context, devices []pure.Device, binaries [][]byte) (pure.Program, []pure.Status, error) {
+	if err := require("clCreateProgramWithBinary", "clReleaseProgram"); err != nil {
+		return 0, nil, err
+	}
+	if len(devices) == 0 || len(binaries) != len(devices) {
+		return 0, nil, fmt.Errorf("cl: %d binaries for %d devices", len(binaries), len(devices))
+	}
+	lengths := make([]pure.Size, len(binaries))
+	pointers := make([]unsafe.Pointer, len(binaries))
+	for i, b := range binaries {
+		if len(b) == 0 {
+			return 0, nil, fmt.Errorf("cl: binary %d is empty", i)
+		}
+		lengths[i] = pure.Size(len(b))
+		pointers[i] = unsafe.Pointer(&b[0])
+	}
+	status := make([]pure.Status, len(binaries))
+	var ret pure.Status
+	p := createProgramWithBinary(ctx, uint32(len(devices)), devices, lengths, pointers, status, &ret)
+	runtime.KeepAlive(binaries)
+	err := pure.StatusToErr(ret)
+	for _, s := range status {
+		err = pure.ErrJoin(err, pure.StatusToErr(s))
+	}
+	if err != nil {
+		if p != pure.Program(0) {
+			pure.ReleaseProgram(p)
+		}
+		return 0, status, err
+	}
+	return p, status, nil
+}
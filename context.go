@@ -0,0 +1,164 @@
+package highCL
+
+import (
+	"errors"
+	pure "github.com/opencl-pure/pureCL"
+	"sync"
+)
+
+// Context spans several devices of one platform,
+// memory objects and programs created in the context are shared by all of its devices
+// and every device of the context has its own command queue
+type Context struct {
+	ctx      pure.Context
+	ids      []pure.Device
+	devices  []*Device
+	programs []*Program
+	platform *Platform
+	cache    *ProgramCache
+	hook     BuildLogHook
+	mu       sync.Mutex // guards programs, cache and hook
+}
+
+// NewContext creates context spanning given devices, all of them must belong to one platform.
+// Given devices stay untouched, use Context.Devices to allocate memory and run kernels in the context
+func NewContext(devices ...*Device) (*Context, error) {
+	if len(devices) == 0 {
+		return nil, errors.New("cl: context needs at least one device")
+	}
+	platform := devices[0].platform
+	c := &Context{platform: platform}
+	for _, d := range devices {
+		if d.platform.p != platform.p {
+			return nil, errors.New("cl: context devices must belong to one platform")
+		}
+		c.ids = append(c.ids, d.id[0])
+	}
+	if err := require("clCreateContext", "clReleaseContext"); err != nil {
+		return nil, err
+	}
+	var ret pure.Status
+	c.ctx = pure.CreateContext(nil, uint32(len(c.ids)), c.ids, nil, nil, &ret)
+	err := pure.StatusToErr(ret)
+	if err != nil {
+		return nil, err
+	}
+	if c.ctx == pure.Context(0) {
+		return nil, ErrUnknown
+	}
+	for _, id := range c.ids {
+		d := &Device{
+			id:       []pure.Device{id},
+			ctx:      c.ctx,
+			platform: platform,
+			context:  c,
+		}
+		d.queue, err = newQueue(d)
+		if err != nil {
+			return nil, pure.ErrJoin(err, c.Release())
+		}
+		c.devices = append(c.devices, d)
+	}
+	return c, nil
+}
+
+// Devices returns devices of the context in the order given to NewContext,
+// buffers allocated on one of them can be passed to kernels of any other
+func (c *Context) Devices() []*Device {
+	return c.devices
+}
+
+// Device returns i-th device of the context
+func (c *Context) Device(i int) *Device {
+	return c.devices[i]
+}
+
+// device first device of the context which is not released, nil when all of them are released
+func (c *Context) device() *Device {
+	for _, d := range c.devices {
+		if !d.Released() {
+			return d
+		}
+	}
+	return nil
+}
+
+// AddProgram compiles program source for all devices of the context
+func (c *Context) AddProgram(source string) (*Program, error) {
+	return c.AddMultipleProgramWithBuildingFlags([]string{source}, "")
+}
+
+// AddMultipleProgram compiles programs sources for all devices of the context
+func (c *Context) AddMultipleProgram(sources []string) (*Program, error) {
+	return c.AddMultipleProgramWithBuildingFlags(sources, "")
+}
+
+// AddProgramWithOptions compiles programs sources with build options for all devices of the context
+func (c *Context) AddProgramWithOptions(sources []string, opts *BuildOptions) (*Program, error) {
+	return c.AddMultipleProgramWithBuildingFlags(sources, opts.String())
+}
+
+// AddMultipleProgramWithBuildingFlags compiles programs sources with flags for all devices of the context,
+// kernels of the program are reachable by Device.Kernel of every context device
+func (c *Context) AddMultipleProgramWithBuildingFlags(sources []string, flags string) (*Program, error) {
+	c.mu.Lock()
+	cache := c.cache
+	c.mu.Unlock()
+	p, err := buildProgramCached(cache, c.ctx, c.ids, sources, flags)
+	if err != nil {
+		return nil, err
+	}
+	return c.built(p), nil
+}
+
+// AddProgramFromBinary creates program from one binary per context device (in Devices order) and builds it with opts,
+// the returned status tells for every device whether the driver accepted its binary
+func (c *Context) AddProgramFromBinary(binaries [][]byte, opts *BuildOptions) (*Program, []pure.Status, error) {
+	p, status, err := createProgramFromBinaries(c.ctx, c.ids, binaries)
+	if err != nil {
+		return nil, status, err
+	}
+	if err = buildCreatedProgram(p, c.ids, opts.String()); err != nil {
+		return nil, status, err
+	}
+	return c.built(p), status, nil
+}
+
+// SetBuildLogHook sets hook receiving non-empty logs of programs successfully built for the context, nil removes it
+func (c *Context) SetBuildLogHook(hook BuildLogHook) {
+	c.mu.Lock()
+	c.hook = hook
+	c.mu.Unlock()
+}
+
+// built wraps successfully built program, adds it to shared programs and reports its build log
+func (c *Context) built(p pure.Program) *Program {
+	prog := &Program{program: p, context: c, list: &c.programs, listMu: &c.mu}
+	c.mu.Lock()
+	c.programs = append(c.programs, prog)
+	hook := c.hook
+	c.mu.Unlock()
+	reportBuildLog(hook, prog)
+	return prog
+}
+
+// Release releases queues and programs of all context devices, shared programs and the context
+func (c *Context) Release() error {
+	if err := require("clReleaseProgram", "clReleaseContext"); err != nil {
+		return err
+	}
+	var result error
+	for _, d := range c.devices {
+		if !d.Released() {
+			result = pure.ErrJoin(result, d.Release())
+		}
+	}
+	c.devices = nil
+	c.mu.Lock()
+	for _, p := range c.programs {
+		result = pure.ErrJoin(result, p.release())
+	}
+	c.programs = nil
+	c.mu.Unlock()
+	return pure.ErrJoin(result, pure.StatusToErr(pure.ReleaseContext(c.ctx)))
+}
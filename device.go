@@ -5,6 +5,7 @@ import (
 	constants "github.com/opencl-pure/constantsCL"
 	pure "github.com/opencl-pure/pureCL"
 	"strings"
+	"sync"
 	"unsafe"
 )
 
@@ -15,35 +16,79 @@ type Device struct {
 	id       []pure.Device
 	ctx      pure.Context
 	queue    pure.CommandQueue
-	programs []pure.Program // only one
+	programs []*Program
 	platform *Platform
+	context  *Context  // shared context, nil when the device owns ctx
+	parent   *Device   // parent of the sub-device, nil for root devices
+	children []*Device // sub-devices not released yet
+	version  *Version  // cached ParsedVersion
+	cache    *ProgramCache
+	objects  []*Program // compiled objects and libraries, they have no kernels
+	mu       sync.Mutex // guards programs, objects, cache, hook and released, programs can be built asynchronously
+	hook     BuildLogHook
+	released bool
 }
 
-// Release releases the device
+// ErrDeviceReleased the device was already released
+var ErrDeviceReleased = errors.New("cl: device is released")
+
+// Release releases the device,
+// device of a shared Context releases only its queue and programs, the context is released by Context.Release
+// which skips devices released before
 func (d *Device) Release() error {
+	if err := require("clReleaseProgram", "clReleaseCommandQueue", "clReleaseContext", "clReleaseDevice"); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	released := d.released
+	d.released = true
+	d.mu.Unlock()
+	if released {
+		return ErrDeviceReleased
+	}
 	var result error
+	for len(d.children) > 0 {
+		result = pure.ErrJoin(result, d.children[0].Release())
+	}
+	if d.parent != nil {
+		d.parent.removeChild(d)
+	}
+	d.mu.Lock()
 	for _, p := range d.programs {
-		if err := pure.StatusToErr(pure.ReleaseProgram(p)); err != nil {
-			result = pure.ErrJoin(result, err)
-		}
+		result = pure.ErrJoin(result, p.release())
+	}
+	d.programs = nil
+	for _, p := range d.objects {
+		result = pure.ErrJoin(result, p.release())
 	}
+	d.objects = nil
+	d.mu.Unlock()
 	if err := pure.StatusToErr(pure.ReleaseCommandQueue(d.queue)); err != nil {
 		result = pure.ErrJoin(result, err)
 	}
+	if d.context != nil {
+		return result
+	}
 	if err := pure.StatusToErr(pure.ReleaseContext(d.ctx)); err != nil {
 		result = pure.ErrJoin(result, err)
 	}
 	return pure.ErrJoin(result, pure.StatusToErr(pure.ReleaseDevice(d.id[0])))
 }
 
+// Released reports whether the device was released by Release
+func (d *Device) Released() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.released
+}
+
+// GetInfoString device info of char[] type
 func (d *Device) GetInfoString(param pure.DeviceInfo) (string, error) {
-	strC := make([]byte, 1024)
-	var strN pure.Size
-	err := pure.StatusToErr(pure.GetDeviceInfo(d.id[0], param, 1024, strC, &strN))
+	info, err := d.getInfo(param)
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(strC[:int(strN)-1])), nil
+	return strings.TrimSpace(strings.TrimRight(string(info), "\x00")), nil
 }
 
 func (d *Device) String() (string, error) {
@@ -57,6 +102,16 @@ func (d *Device) Name() (string, error) {
 	return d.GetInfoString(constants.CL_DEVICE_NAME)
 }
 
+// deviceName name of the device id, e.g. of a device associated with a program
+func deviceName(id pure.Device) (string, error) {
+	return deviceString(id, constants.CL_DEVICE_NAME)
+}
+
+// deviceString device info of char[] type of the device id
+func deviceString(id pure.Device, param pure.DeviceInfo) (string, error) {
+	return (&Device{id: []pure.Device{id}}).GetInfoString(param)
+}
+
 // Vendor device info - vendor
 func (d *Device) Vendor() (string, error) {
 	return d.GetInfoString(constants.CL_DEVICE_VENDOR)
@@ -122,31 +177,126 @@ func (d *Device) AddMultipleProgram(sources []string) (*Program, error) {
 	return d.AddMultipleProgramWithBuildingFlags(sources, "")
 }
 
+// AddProgramWithOptions compiles programs sources with build options
+func (d *Device) AddProgramWithOptions(sources []string, opts *BuildOptions) (*Program, error) {
+	return d.AddMultipleProgramWithBuildingFlags(sources, opts.String())
+}
+
 // AddMultipleProgramWithBuildingFlags compiles programs sources with flags, this function is very sensitive to strings coding
-// add flags only when you know what you do
+// add flags only when you know what you do, failed build returns *BuildError with logs of the compiler
 func (d *Device) AddMultipleProgramWithBuildingFlags(sources []string, flags string) (*Program, error) {
+	cache, hook := d.buildSettings()
+	return d.addProgram(sources, flags, cache, hook)
+}
+
+// addProgram builds programs sources with the cache and adds the program, its build log is reported to the hook
+func (d *Device) addProgram(sources []string, flags string, cache *ProgramCache, hook BuildLogHook) (*Program, error) {
+	p, err := buildProgramCached(cache, d.ctx, d.id, sources, flags)
+	if err != nil {
+		return nil, err
+	}
+	return d.add(p, &d.programs, hook), nil
+}
+
+// buildSettings program cache and build log hook of the device
+func (d *Device) buildSettings() (*ProgramCache, BuildLogHook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cache, d.hook
+}
+
+// AddProgramFromBinary creates program from binaries (e.g. exported by Program.GetBinaries) and builds it with opts,
+// the device has exactly one binary, the returned status tells whether the driver accepted it
+func (d *Device) AddProgramFromBinary(binaries [][]byte, opts *BuildOptions) (*Program, []pure.Status, error) {
+	p, status, err := createProgramFromBinaries(d.ctx, d.id, binaries)
+	if err != nil {
+		return nil, status, err
+	}
+	if err = buildCreatedProgram(p, d.id, opts.String()); err != nil {
+		return nil, status, err
+	}
+	return d.built(p, &d.programs), status, nil
+}
+
+// ReplaceProgram builds new program from sources and releases the old one,
+// the old program stays untouched when the build fails, kernels of the old program can not run after the replacement
+func (d *Device) ReplaceProgram(old *Program, sources []string, opts *BuildOptions) (*Program, error) {
+	if old.device != d || old.list != &d.programs {
+		return nil, errors.New("cl: program does not belong to the device")
+	}
+	p, err := d.AddProgramWithOptions(sources, opts)
+	if err != nil {
+		return nil, err
+	}
+	return p, old.Release()
+}
+
+// SetBuildLogHook sets hook receiving non-empty logs of programs successfully built by the device, nil removes it
+func (d *Device) SetBuildLogHook(hook BuildLogHook) {
+	d.mu.Lock()
+	d.hook = hook
+	d.mu.Unlock()
+}
+
+// built wraps successfully built program, adds it to the list and reports its build log to the hook of the device
+func (d *Device) built(p pure.Program, list *[]*Program) *Program {
+	_, hook := d.buildSettings()
+	return d.add(p, list, hook)
+}
+
+// add wraps successfully built program, adds it to the list and reports its build log to the hook
+func (d *Device) add(p pure.Program, list *[]*Program, hook BuildLogHook) *Program {
+	prog := &Program{program: p, device: d, list: list, listMu: &d.mu}
+	d.mu.Lock()
+	*list = append(*list, prog)
+	d.mu.Unlock()
+	reportBuildLog(hook, prog)
+	return prog
+}
+
+// buildProgram creates program from sources in ctx and builds it for all devices
+func buildProgram(ctx pure.Context, devices []pure.Device, sources []string, flags string) (pure.Program, error) {
+	if err := require("clCreateProgramWithSource", "clBuildProgram", "clGetProgramBuildInfo", "clReleaseProgram"); err != nil {
+		return 0, err
+	}
 	var ret pure.Status
-	p := pure.CreateProgramWithSource(d.ctx, pure.Size(len(sources)), sources, nil, &ret)
+	p := pure.CreateProgramWithSource(ctx, pure.Size(len(sources)), sources, nil, &ret)
 	err := pure.StatusToErr(ret)
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if err = buildCreatedProgram(p, devices, flags); err != nil {
+		return 0, err
 	}
+	return p, nil
+}
 
+// buildCreatedProgram builds created program for all devices, the program is released when the build fails,
+// the error is *BuildError
+func buildCreatedProgram(p pure.Program, devices []pure.Device, flags string) error {
+	if err := require("clBuildProgram", "clGetProgramBuildInfo", "clReleaseProgram"); err != nil {
+		return err
+	}
 	flagsB := []byte{'\x00'}
 	if len(flags) > 0 {
 		flagsB = append([]byte(flags), byte('\x00'))
 	}
-	ret = pure.BuildProgram(p, 1, d.id, flagsB, nil, nil)
+	ret := pure.BuildProgram(p, uint32(len(devices)), devices, flagsB, nil, nil)
 	if ret != constants.CL_SUCCESS {
-		if ret == constants.CL_BUILD_PROGRAM_FAILURE {
-			var n pure.Size
-			pure.GetProgramBuildInfo(p, d.id[0], constants.CL_PROGRAM_BUILD_LOG, 0, nil, &n)
-			log := make([]byte, int(n))
-			pure.GetProgramBuildInfo(p, d.id[0], constants.CL_PROGRAM_BUILD_LOG, n, unsafe.Pointer(&log[0]), nil)
-			return nil, errors.New(string(log[:int(n)-1]))
-		}
-		return nil, pure.StatusToErr(ret)
-	}
-	d.programs = append(d.programs, p)
-	return &Program{program: p}, nil
+		defer pure.ReleaseProgram(p)
+		return newBuildError(p, devices, ret, flags)
+	}
+	return nil
+}
+
+// buildLog build (compile, link) log of the program for the device, empty when it can not be read
+func buildLog(p pure.Program, device pure.Device) string {
+	var n pure.Size
+	pure.GetProgramBuildInfo(p, device, constants.CL_PROGRAM_BUILD_LOG, 0, nil, &n)
+	if n == 0 {
+		return ""
+	}
+	log := make([]byte, int(n))
+	pure.GetProgramBuildInfo(p, device, constants.CL_PROGRAM_BUILD_LOG, n, unsafe.Pointer(&log[0]), nil)
+	return strings.TrimRight(string(log), "\x00")
 }
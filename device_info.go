@@ -0,0 +1,235 @@
+package highCL
+
+import (
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"unsafe"
+)
+
+// DeviceInfo typed snapshot of the most used device parameters
+type DeviceInfo struct {
+	// identification
+	Type           pure.DeviceType
+	VendorID       uint32
+	Name           string
+	Vendor         string
+	DriverVersion  string
+	Profile        string
+	Version        string
+	OpenCLCVersion string
+	Extensions     string
+	BuiltInKernels string
+	// compute
+	MaxComputeUnits       uint32
+	MaxClockFrequency     uint32
+	MaxWorkItemDimensions uint32
+	MaxWorkItemSizes      []int
+	MaxWorkGroupSize      int
+	AddressBits           uint32
+	// memory
+	GlobalMemSize          uint64
+	GlobalMemCacheSize     uint64
+	GlobalMemCachelineSize uint32
+	GlobalMemCacheType     uint32
+	LocalMemSize           uint64
+	LocalMemType           uint32
+	MaxMemAllocSize        uint64
+	MaxConstantBufferSize  uint64
+	MaxConstantArgs        uint32
+	MaxParameterSize       int
+	MemBaseAddrAlign       uint32
+	HostUnifiedMemory      bool
+	ErrorCorrectionSupport bool
+	// images
+	ImageSupport      bool
+	MaxReadImageArgs  uint32
+	MaxWriteImageArgs uint32
+	MaxSamplers       uint32
+	Image2DMaxWidth   int
+	Image2DMaxHeight  int
+	Image3DMaxWidth   int
+	Image3DMaxHeight  int
+	Image3DMaxDepth   int
+	// floating point, execution and queues (bitfields)
+	SingleFPConfig        uint64
+	DoubleFPConfig        uint64
+	ExecutionCapabilities uint64
+	QueueProperties       uint64
+	// misc
+	ProfilingTimerResolution int
+	EndianLittle             bool
+	Available                bool
+	CompilerAvailable        bool
+	LinkerAvailable          bool
+	PrintfBufferSize         int
+}
+
+// Info queries all parameters of DeviceInfo, it fills as much as possible,
+// parameters unknown to older devices are left zero and their errors are joined into the returned error
+func (d *Device) Info() (*DeviceInfo, error) {
+	info := &DeviceInfo{}
+	var result error
+	str := func(dst *string, param pure.DeviceInfo) {
+		var err error
+		*dst, err = d.GetInfoString(param)
+		result = pure.ErrJoin(result, err)
+	}
+	u32 := func(dst *uint32, param pure.DeviceInfo) {
+		var err error
+		*dst, err = d.GetInfoUint32(param)
+		result = pure.ErrJoin(result, err)
+	}
+	u64 := func(dst *uint64, param pure.DeviceInfo) {
+		var err error
+		*dst, err = d.GetInfoUint64(param)
+		result = pure.ErrJoin(result, err)
+	}
+	size := func(dst *int, param pure.DeviceInfo) {
+		var err error
+		*dst, err = d.GetInfoSize(param)
+		result = pure.ErrJoin(result, err)
+	}
+	boolean := func(dst *bool, param pure.DeviceInfo) {
+		var err error
+		*dst, err = d.GetInfoBool(param)
+		result = pure.ErrJoin(result, err)
+	}
+
+	var deviceType uint64
+	u64(&deviceType, constants.CL_DEVICE_TYPE)
+	info.Type = pure.DeviceType(deviceType)
+	u32(&info.VendorID, constants.CL_DEVICE_VENDOR_ID)
+	str(&info.Name, constants.CL_DEVICE_NAME)
+	str(&info.Vendor, constants.CL_DEVICE_VENDOR)
+	str(&info.DriverVersion, constants.CL_DRIVER_VERSION)
+	str(&info.Profile, constants.CL_DEVICE_PROFILE)
+	str(&info.Version, constants.CL_DEVICE_VERSION)
+	str(&info.OpenCLCVersion, constants.CL_DEVICE_OPENCL_C_VERSION)
+	str(&info.Extensions, constants.CL_DEVICE_EXTENSIONS)
+	str(&info.BuiltInKernels, constants.CL_DEVICE_BUILT_IN_KERNELS)
+
+	u32(&info.MaxComputeUnits, constants.CL_DEVICE_MAX_COMPUTE_UNITS)
+	u32(&info.MaxClockFrequency, constants.CL_DEVICE_MAX_CLOCK_FREQUENCY)
+	u32(&info.MaxWorkItemDimensions, constants.CL_DEVICE_MAX_WORK_ITEM_DIMENSIONS)
+	sizes, err := d.GetInfoSizeArray(constants.CL_DEVICE_MAX_WORK_ITEM_SIZES)
+	info.MaxWorkItemSizes = sizes
+	result = pure.ErrJoin(result, err)
+	size(&info.MaxWorkGroupSize, constants.CL_DEVICE_MAX_WORK_GROUP_SIZE)
+	u32(&info.AddressBits, constants.CL_DEVICE_ADDRESS_BITS)
+
+	u64(&info.GlobalMemSize, constants.CL_DEVICE_GLOBAL_MEM_SIZE)
+	u64(&info.GlobalMemCacheSize, constants.CL_DEVICE_GLOBAL_MEM_CACHE_SIZE)
+	u32(&info.GlobalMemCachelineSize, constants.CL_DEVICE_GLOBAL_MEM_CACHELINE_SIZE)
+	u32(&info.GlobalMemCacheType, constants.CL_DEVICE_GLOBAL_MEM_CACHE_TYPE)
+	u64(&info.LocalMemSize, constants.CL_DEVICE_LOCAL_MEM_SIZE)
+	u32(&info.LocalMemType, constants.CL_DEVICE_LOCAL_MEM_TYPE)
+	u64(&info.MaxMemAllocSize, constants.CL_DEVICE_MAX_MEM_ALLOC_SIZE)
+	u64(&info.MaxConstantBufferSize, constants.CL_DEVICE_MAX_CONSTANT_BUFFER_SIZE)
+	u32(&info.MaxConstantArgs, constants.CL_DEVICE_MAX_CONSTANT_ARGS)
+	size(&info.MaxParameterSize, constants.CL_DEVICE_MAX_PARAMETER_SIZE)
+	u32(&info.MemBaseAddrAlign, constants.CL_DEVICE_MEM_BASE_ADDR_ALIGN)
+	boolean(&info.HostUnifiedMemory, constants.CL_DEVICE_HOST_UNIFIED_MEMORY)
+	boolean(&info.ErrorCorrectionSupport, constants.CL_DEVICE_ERROR_CORRECTION_SUPPORT)
+
+	boolean(&info.ImageSupport, constants.CL_DEVICE_IMAGE_SUPPORT)
+	u32(&info.MaxReadImageArgs, constants.CL_DEVICE_MAX_READ_IMAGE_ARGS)
+	u32(&info.MaxWriteImageArgs, constants.CL_DEVICE_MAX_WRITE_IMAGE_ARGS)
+	u32(&info.MaxSamplers, constants.CL_DEVICE_MAX_SAMPLERS)
+	size(&info.Image2DMaxWidth, constants.CL_DEVICE_IMAGE2D_MAX_WIDTH)
+	size(&info.Image2DMaxHeight, constants.CL_DEVICE_IMAGE2D_MAX_HEIGHT)
+	size(&info.Image3DMaxWidth, constants.CL_DEVICE_IMAGE3D_MAX_WIDTH)
+	size(&info.Image3DMaxHeight, constants.CL_DEVICE_IMAGE3D_MAX_HEIGHT)
+	size(&info.Image3DMaxDepth, constants.CL_DEVICE_IMAGE3D_MAX_DEPTH)
+
+	u64(&info.SingleFPConfig, constants.CL_DEVICE_SINGLE_FP_CONFIG)
+	u64(&info.DoubleFPConfig, constants.CL_DEVICE_DOUBLE_FP_CONFIG)
+	u64(&info.ExecutionCapabilities, constants.CL_DEVICE_EXECUTION_CAPABILITIES)
+	u64(&info.QueueProperties, constants.CL_DEVICE_QUEUE_PROPERTIES)
+
+	size(&info.ProfilingTimerResolution, constants.CL_DEVICE_PROFILING_TIMER_RESOLUTION)
+	boolean(&info.EndianLittle, constants.CL_DEVICE_ENDIAN_LITTLE)
+	boolean(&info.Available, constants.CL_DEVICE_AVAILABLE)
+	boolean(&info.CompilerAvailable, constants.CL_DEVICE_COMPILER_AVAILABLE)
+	boolean(&info.LinkerAvailable, constants.CL_DEVICE_LINKER_AVAILABLE)
+	size(&info.PrintfBufferSize, constants.CL_DEVICE_PRINTF_BUFFER_SIZE)
+	return info, result
+}
+
+// GetInfoUint32 device info of cl_uint type
+func (d *Device) GetInfoUint32(param pure.DeviceInfo) (uint32, error) {
+	var v uint32
+	err := d.getInfoFixed(param, unsafe.Sizeof(v), unsafe.Pointer(&v))
+	return v, err
+}
+
+// GetInfoUint64 device info of cl_ulong type
+func (d *Device) GetInfoUint64(param pure.DeviceInfo) (uint64, error) {
+	var v uint64
+	err := d.getInfoFixed(param, unsafe.Sizeof(v), unsafe.Pointer(&v))
+	return v, err
+}
+
+// GetInfoBitfield device info of cl_bitfield type (cl_device_type, cl_device_fp_config, ...)
+func (d *Device) GetInfoBitfield(param pure.DeviceInfo) (uint64, error) {
+	return d.GetInfoUint64(param)
+}
+
+// GetInfoSize device info of size_t type
+func (d *Device) GetInfoSize(param pure.DeviceInfo) (int, error) {
+	var v pure.Size
+	err := d.getInfoFixed(param, unsafe.Sizeof(v), unsafe.Pointer(&v))
+	return int(v), err
+}
+
+// GetInfoBool device info of cl_bool type
+func (d *Device) GetInfoBool(param pure.DeviceInfo) (bool, error) {
+	v, err := d.GetInfoUint32(param)
+	return v != constants.CL_FALSE, err
+}
+
+// GetInfoSizeArray device info of size_t[] type
+func (d *Device) GetInfoSizeArray(param pure.DeviceInfo) ([]int, error) {
+	info, err := d.getInfo(param)
+	if err != nil {
+		return nil, err
+	}
+	n := len(info) / int(unsafe.Sizeof(pure.Size(0)))
+	if n == 0 {
+		return []int{}, nil
+	}
+	sizes := unsafe.Slice((*pure.Size)(unsafe.Pointer(&info[0])), n)
+	res := make([]int, n)
+	for i, s := range sizes {
+		res[i] = int(s)
+	}
+	return res, nil
+}
+
+// getInfo returns raw bytes of device info with any size
+func (d *Device) getInfo(param pure.DeviceInfo) ([]byte, error) {
+	if err := require("clGetDeviceInfo"); err != nil {
+		return nil, err
+	}
+	var n pure.Size
+	err := pure.StatusToErr(pure.GetDeviceInfo(d.id[0], param, 0, nil, &n))
+	if err != nil {
+		return nil, err
+	}
+	info := make([]byte, int(n))
+	if n == 0 {
+		return info, nil
+	}
+	err = pure.StatusToErr(pure.GetDeviceInfo(d.id[0], param, n, info, nil))
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// getInfoFixed writes device info of known size directly to ptr
+func (d *Device) getInfoFixed(param pure.DeviceInfo, size uintptr, ptr unsafe.Pointer) error {
+	if err := require("clGetDeviceInfo"); err != nil {
+		return err
+	}
+	return pure.StatusToErr(pure.GetDeviceInfo(d.id[0], param, pure.Size(size), unsafe.Slice((*byte)(ptr), size), nil))
+}
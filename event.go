@@ -1,7 +1,10 @@
 package highCL
 
 import (
+	constants "github.com/opencl-pure/constantsCL"
 	pure "github.com/opencl-pure/pureCL"
+	"time"
+	"unsafe"
 )
 
 type Event struct {
@@ -10,11 +13,55 @@ type Event struct {
 
 // Wait on the host thread for commands identified by event objects to complete. Returns an error regarding the outcome of the associated task.
 func (event *Event) Wait() error {
+	if err := require("clWaitForEvents"); err != nil {
+		return err
+	}
 	list := []pure.Event{event.event}
 	return pure.StatusToErr(pure.WaitForEvents(1, list))
 }
 
 // Decrements the event reference count.
 func (event *Event) Release() error {
+	if err := require("clReleaseEvent"); err != nil {
+		return err
+	}
 	return pure.StatusToErr(pure.ReleaseEvent(event.event))
 }
+
+// EventProfile device timestamps of the command in nanoseconds,
+// available only for events of queues with QueueOptions.Profiling
+type EventProfile struct {
+	Queued uint64
+	Submit uint64
+	Start  uint64
+	End    uint64
+}
+
+// Duration execution time of the command on the device
+func (p *EventProfile) Duration() time.Duration {
+	return time.Duration(p.End - p.Start)
+}
+
+// Profile returns timestamps of the command, the command must be completed (see Wait)
+func (event *Event) Profile() (*EventProfile, error) {
+	if err := require("clGetEventProfilingInfo"); err != nil {
+		return nil, err
+	}
+	p := &EventProfile{}
+	params := []struct {
+		name uint32
+		dst  *uint64
+	}{
+		{constants.CL_PROFILING_COMMAND_QUEUED, &p.Queued},
+		{constants.CL_PROFILING_COMMAND_SUBMIT, &p.Submit},
+		{constants.CL_PROFILING_COMMAND_START, &p.Start},
+		{constants.CL_PROFILING_COMMAND_END, &p.End},
+	}
+	for _, param := range params {
+		err := pure.StatusToErr(getEventProfilingInfo(event.event, param.name, pure.Size(unsafe.Sizeof(*param.dst)), unsafe.Pointer(param.dst), nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
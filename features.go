@@ -0,0 +1,139 @@
+package highCL
+
+import (
+	pure "github.com/opencl-pure/pureCL"
+	"reflect"
+)
+
+// ErrNotSupported the API needs OpenCL function (Feature) which the loaded library does not export,
+// Init was called with lower version or the device implements lower OpenCL version
+type ErrNotSupported struct {
+	Feature string
+	Reason  string
+}
+
+func (e ErrNotSupported) Error() string {
+	if e.Reason == "" {
+		return "cl: " + e.Feature + " is not supported"
+	}
+	return "cl: " + e.Feature + " is not supported: " + e.Reason
+}
+
+// functions all OpenCL functions used by highCL with pointers to their variables
+var functions = map[string]interface{}{
+	// pureCL
+	"clGetPlatformIDs":          &pure.GetPlatformIDs,
+	"clGetPlatformInfo":         &pure.GetPlatformInfo,
+	"clGetDeviceIDs":            &pure.GetDeviceIDs,
+	"clGetDeviceInfo":           &pure.GetDeviceInfo,
+	"clReleaseDevice":           &pure.ReleaseDevice,
+	"clReleaseEvent":            &pure.ReleaseEvent,
+	"clWaitForEvents":           &pure.WaitForEvents,
+	"clCreateContext":           &pure.CreateContext,
+	"clReleaseContext":          &pure.ReleaseContext,
+	"clCreateProgramWithSource": &pure.CreateProgramWithSource,
+	"clCreateBuffer":            &pure.CreateBuffer,
+	"clCreateImage2D":           &pure.CreateImage2D,
+	"clCreateCommandQueue":      &pure.CreateCommandQueue,
+	"clEnqueueNDRangeKernel":    &pure.EnqueueNDRangeKernel,
+	"clEnqueueReadBuffer":       &pure.EnqueueReadBuffer,
+	"clEnqueueWriteBuffer":      &pure.EnqueueWriteBuffer,
+	"clEnqueueReadImage":        &pure.EnqueueReadImage,
+	"clEnqueueWriteImage":       &pure.EnqueueWriteImage,
+	"clFinish":                  &pure.FinishCommandQueue,
+	"clFlush":                   &pure.FlushCommandQueue,
+	"clReleaseCommandQueue":     &pure.ReleaseCommandQueue,
+	"clBuildProgram":            &pure.BuildProgram,
+	"clGetProgramBuildInfo":     &pure.GetProgramBuildInfo,
+	"clGetProgramInfo":          &pure.GetProgramInfo,
+	"clCreateKernel":            &pure.CreateKernel,
+	"clReleaseProgram":          &pure.ReleaseProgram,
+	"clSetKernelArg":            &pure.SetKernelArg,
+	"clReleaseKernel":           &pure.ReleaseKernel,
+	"clReleaseMemObject":        &pure.ReleaseMemObject,
+	// highCL
+	"clCreateCommandQueueWithProperties": &createCommandQueueWithProperties,
+	"clGetEventProfilingInfo":            &getEventProfilingInfo,
+	"clCreateSubDevices":                 &createSubDevices,
+	"clCreateProgramWithBinary":          &createProgramWithBinary,
+	"clCreateProgramWithIL":              &createProgramWithIL,
+	"clCompileProgram":                   &compileProgram,
+	"clLinkProgram":                      &linkProgram,
+	"clGetKernelInfo":                    &getKernelInfo,
+	"clGetKernelArgInfo":                 &getKernelArgInfo,
+	"clGetKernelWorkGroupInfo":           &getKernelWorkGroupInfo,
+}
+
+// functionVersions minimal OpenCL version of the device for functions newer than 1.0
+var functionVersions = map[string]Version{
+	"clCreateCommandQueueWithProperties": {Major: 2, Minor: 0},
+	"clCreateSubDevices":                 {Major: 1, Minor: 2},
+	"clCreateProgramWithIL":              {Major: 2, Minor: 1},
+	"clCompileProgram":                   {Major: 1, Minor: 2},
+	"clLinkProgram":                      {Major: 1, Minor: 2},
+	"clGetKernelArgInfo":                 {Major: 1, Minor: 2},
+}
+
+// Loaded reports whether OpenCL function (e.g. "clCreateSubDevices") was loaded by Init,
+// it depends on the library and on the version given to Init
+func Loaded(function string) bool {
+	fptr, ok := functions[function]
+	return ok && !reflect.ValueOf(fptr).Elem().IsNil()
+}
+
+// LoadedFunctions reports for all OpenCL functions used by highCL whether they were loaded by Init
+func LoadedFunctions() map[string]bool {
+	res := make(map[string]bool, len(functions))
+	for name := range functions {
+		res[name] = Loaded(name)
+	}
+	return res
+}
+
+// Supports reports whether OpenCL function is loaded and the device implements it
+func (d *Device) Supports(function string) bool {
+	return d.require(function) == nil
+}
+
+// require returns ErrNotSupported for first function which is not loaded
+func require(functions ...string) error {
+	for _, f := range functions {
+		if !Loaded(f) {
+			return ErrNotSupported{Feature: f, Reason: "function is not loaded"}
+		}
+	}
+	return nil
+}
+
+// require returns ErrNotSupported for first function which is not loaded or is newer than the device
+func (d *Device) require(functions ...string) error {
+	if err := require(functions...); err != nil {
+		return err
+	}
+	for _, f := range functions {
+		minVersion, ok := functionVersions[f]
+		if !ok {
+			continue
+		}
+		version, err := d.openCLVersion()
+		if err != nil {
+			return err
+		}
+		if version.Compare(minVersion) < 0 {
+			return ErrNotSupported{Feature: f, Reason: "device implements OpenCL " + version.String() + ", needs " + minVersion.String()}
+		}
+	}
+	return nil
+}
+
+// openCLVersion cached ParsedVersion
+func (d *Device) openCLVersion() (Version, error) {
+	if d.version == nil {
+		version, err := d.ParsedVersion()
+		if err != nil {
+			return Version{}, err
+		}
+		d.version = &version
+	}
+	return *d.version, nil
+}
@@ -0,0 +1,64 @@
+package highCL
+
+import (
+	"github.com/ebitengine/purego"
+	pure "github.com/opencl-pure/pureCL"
+	"reflect"
+	"unsafe"
+)
+
+// OpenCL functions which pureCL does not wrap (or wraps incompatibly), nil when the library does not export them
+var (
+	// createCommandQueueWithProperties this wrap opencl clCreateCommandQueueWithProperties with zero terminated properties list,
+	// pure.CreateCommandQueueWithProperties passes the properties by value, so only no properties work there
+	createCommandQueueWithProperties func(ctx pure.Context, device pure.Device, properties []uint64, errCodeRet *pure.Status) pure.CommandQueue = nil
+	// getEventProfilingInfo this wrap opencl clGetEventProfilingInfo
+	getEventProfilingInfo func(event pure.Event, param uint32, paramValueSize pure.Size, paramValue unsafe.Pointer, paramValueSizeRet *pure.Size) pure.Status = nil
+	// createSubDevices this wrap opencl clCreateSubDevices (OpenCL 1.2), properties is zero terminated cl_device_partition_property list
+	createSubDevices func(device pure.Device, properties []int, numDevices uint32, outDevices []pure.Device, numDevicesRet *uint32) pure.Status = nil
+	// createProgramWithBinary this wrap opencl clCreateProgramWithBinary, binaries holds one pointer per device
+	createProgramWithBinary func(ctx pure.Context, numDevices uint32, devices []pure.Device, lengths []pure.Size, binaries []unsafe.Pointer, binaryStatus []pure.Status, errCodeRet *pure.Status) pure.Program = nil
+	// createProgramWithIL this wrap opencl clCreateProgramWithIL (OpenCL 2.1)
+	createProgramWithIL func(ctx pure.Context, il []byte, length pure.Size, errCodeRet *pure.Status) pure.Program = nil
+	// compileProgram this wrap opencl clCompileProgram (OpenCL 1.2), headerNames holds zero terminated strings
+	compileProgram func(program pure.Program, numDevices uint32, devices []pure.Device, options []byte, numHeaders uint32, headers []pure.Program, headerNames []unsafe.Pointer, notify uintptr, userData unsafe.Pointer) pure.Status = nil
+	// linkProgram this wrap opencl clLinkProgram (OpenCL 1.2)
+	linkProgram func(ctx pure.Context, numDevices uint32, devices []pure.Device, options []byte, numPrograms uint32, programs []pure.Program, notify uintptr, userData unsafe.Pointer, errCodeRet *pure.Status) pure.Program = nil
+	// getKernelInfo this wrap opencl clGetKernelInfo
+	getKernelInfo func(kernel pure.Kernel, param uint32, paramValueSize pure.Size, paramValue unsafe.Pointer, paramValueSizeRet *pure.Size) pure.Status = nil
+	// getKernelArgInfo this wrap opencl clGetKernelArgInfo (OpenCL 1.2)
+	getKernelArgInfo func(kernel pure.Kernel, argIndex uint32, param uint32, paramValueSize pure.Size, paramValue unsafe.Pointer, paramValueSizeRet *pure.Size) pure.Status = nil
+	// getKernelWorkGroupInfo this wrap opencl clGetKernelWorkGroupInfo
+	getKernelWorkGroupInfo func(kernel pure.Kernel, device pure.Device, param uint32, paramValueSize pure.Size, paramValue unsafe.Pointer, paramValueSizeRet *pure.Size) pure.Status = nil
+)
+
+// initFunctions registers functions which are not part of pureCL
+func initFunctions(handle uintptr, version pure.Version) {
+	registerFunc(&getEventProfilingInfo, handle, "clGetEventProfilingInfo")
+	registerFunc(&createSubDevices, handle, "clCreateSubDevices")
+	registerFunc(&createProgramWithBinary, handle, "clCreateProgramWithBinary")
+	registerFunc(&createProgramWithIL, handle, "clCreateProgramWithIL")
+	registerFunc(&compileProgram, handle, "clCompileProgram")
+	registerFunc(&linkProgram, handle, "clLinkProgram")
+	registerFunc(&getKernelInfo, handle, "clGetKernelInfo")
+	registerFunc(&getKernelArgInfo, handle, "clGetKernelArgInfo")
+	registerFunc(&getKernelWorkGroupInfo, handle, "clGetKernelWorkGroupInfo")
+	if version == pure.Version2_0 || version == pure.Version3_0 {
+		registerFunc(&createCommandQueueWithProperties, handle, "clCreateCommandQueueWithProperties")
+	} else {
+		createCommandQueueWithProperties = nil
+	}
+}
+
+// registerFunc registers function name of the library into fptr, fptr is set to nil when the library does not export it
+func registerFunc(fptr interface{}, handle uintptr, name string) (ok bool) {
+	fn := reflect.ValueOf(fptr).Elem()
+	fn.Set(reflect.Zero(fn.Type()))
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	purego.RegisterLibFunc(fptr, handle, name)
+	return true
+}
@@ -3,11 +3,9 @@ module github.com/opencl-pure/highCL
 go 1.20
 
 require (
+	github.com/ebitengine/purego v0.6.1
 	github.com/opencl-pure/constantsCL v0.0.0-20240317165126-e939496b9300
 	github.com/opencl-pure/pureCL v0.0.0-20240318184024-9bd3c662ac67
 )
 
-require (
-	github.com/ebitengine/purego v0.6.1 // indirect
-	golang.org/x/sys v0.18.0 // indirect
-)
+require golang.org/x/sys v0.18.0 // indirect
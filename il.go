@@ -0,0 +1,49 @@
+package highCL
+
+import (
+	"errors"
+	pure "github.com/opencl-pure/pureCL"
+	"strings"
+)
+
+// deviceILVersion CL_DEVICE_IL_VERSION, OpenCL 2.1 device info which constantsCL does not define
+const deviceILVersion = 0x105B
+
+// ILVersion device info - intermediate languages supported by the device separated by space (e.g. "SPIR-V_1.2"),
+// empty when the device does not support programs in intermediate language
+func (d *Device) ILVersion() (string, error) {
+	var notSupported ErrNotSupported
+	if err := d.require("clCreateProgramWithIL"); errors.As(err, &notSupported) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return d.GetInfoString(deviceILVersion)
+}
+
+// AddProgramIL creates program from intermediate language (SPIR-V) and builds it with opts,
+// the device must implement OpenCL 2.1 and report IL support, kernels are reachable by Device.Kernel
+func (d *Device) AddProgramIL(il []byte, opts *BuildOptions) (*Program, error) {
+	if err := d.require("clCreateProgramWithIL", "clReleaseProgram"); err != nil {
+		return nil, err
+	}
+	version, err := d.ILVersion()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(version) == "" {
+		return nil, ErrNotSupported{Feature: "clCreateProgramWithIL", Reason: "device reports no intermediate language"}
+	}
+	if len(il) == 0 {
+		return nil, errors.New("cl: intermediate language is empty")
+	}
+	var ret pure.Status
+	p := createProgramWithIL(d.ctx, il, pure.Size(len(il)), &ret)
+	if err = pure.StatusToErr(ret); err != nil {
+		return nil, err
+	}
+	if err = buildCreatedProgram(p, d.id, opts.String()); err != nil {
+		return nil, err
+	}
+	return d.built(p, &d.programs), nil
+}
@@ -56,6 +56,9 @@ func (d *Device) NewImageFromImage2D(img image.Image) (*Image, error) {
 }
 
 func (d *Device) newImage2D(imageType ImageType, bounds image.Rectangle, rowPitch int, data unsafe.Pointer) (*Image, error) {
+	if err := require("clCreateImage2D"); err != nil {
+		return nil, err
+	}
 	var format = &pure.ImageFormat{ChannelOrder: 0, ChannelType: 0}
 	switch imageType {
 	case ImageTypeGray:
@@ -141,6 +144,10 @@ func imgData(i image.Image) []byte {
 
 func (img *Image) copy(data []byte) <-chan error {
 	ch := make(chan error, 1)
+	if err := require("clEnqueueWriteImage", "clWaitForEvents", "clReleaseEvent"); err != nil {
+		ch <- err
+		return ch
+	}
 	cOrigin := [3]pure.Size{0, 0, 0}
 	cRegion := [3]pure.Size{pure.Size(img.bounds.Dx()), pure.Size(img.bounds.Dy()), 1}
 	var event pure.Event
@@ -175,6 +182,9 @@ func (img *Image) copy(data []byte) <-chan error {
 
 // Data gets data from an image buffer and returns an image.Image
 func (img *Image) Data() (image.Image, error) {
+	if err := require("clEnqueueReadImage"); err != nil {
+		return nil, err
+	}
 	data := make([]byte, img.buf.size)
 	cOrigin := [3]pure.Size{0, 0, 0}
 	cRegion := [3]pure.Size{pure.Size(img.bounds.Dx()), pure.Size(img.bounds.Dy()), 1}
@@ -8,25 +8,72 @@ import (
 	"unsafe"
 )
 
-// Kernel returns an kernel
-// if retrieving the kernel didn't complete the function will panic
+// Kernel returns kernel of the device programs (and of shared programs of its context),
+// the name must be defined by exactly one program, otherwise use Program.Kernel
 func (d *Device) Kernel(name string) (*Kernel, error) {
-	var k pure.Kernel
-	var ret pure.Status
-	for _, p := range d.programs {
-		k = pure.CreateKernel(p, name, &ret)
+	if err := require("clCreateKernel", "clReleaseKernel"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	programs := append([]*Program{}, d.programs...)
+	d.mu.Unlock()
+	if d.context != nil {
+		d.context.mu.Lock()
+		programs = append(programs, d.context.programs...)
+		d.context.mu.Unlock()
+	}
+	var found pure.Kernel
+	var foundProgram *Program
+	n := 0
+	for _, p := range programs {
+		if p.Released() {
+			continue // released concurrently
+		}
+		var ret pure.Status
+		k := pure.CreateKernel(p.program, name, &ret)
 		if ret == constants.CL_INVALID_KERNEL_NAME {
 			continue
 		}
 		if ret != constants.CL_SUCCESS {
+			if n > 0 {
+				pure.ReleaseKernel(found)
+			}
 			return nil, pure.StatusToErr(ret)
 		}
-		break
+		n++
+		if n == 1 {
+			found, foundProgram = k, p
+		} else {
+			pure.ReleaseKernel(k)
+		}
 	}
-	if ret == constants.CL_INVALID_KERNEL_NAME {
-		return nil, pure.StatusToErr(ret)
+	switch {
+	case n == 0:
+		return nil, ErrUnknownKernel{Name: name}
+	case n > 1:
+		pure.ReleaseKernel(found)
+		return nil, ErrAmbiguousKernel{Name: name, Programs: n}
 	}
-	return newKernel(d, k), nil
+	return newKernel(d, foundProgram, found, name), nil
+}
+
+// ErrUnknownKernel no program defines the kernel
+type ErrUnknownKernel struct {
+	Name string
+}
+
+func (e ErrUnknownKernel) Error() string {
+	return fmt.Sprintf("cl: unknown kernel %q", e.Name)
+}
+
+// ErrAmbiguousKernel several programs define the kernel, use Program.Kernel to choose one
+type ErrAmbiguousKernel struct {
+	Name     string
+	Programs int
+}
+
+func (e ErrAmbiguousKernel) Error() string {
+	return fmt.Sprintf("cl: kernel %q is defined by %d programs, use Program.Kernel", e.Name, e.Programs)
 }
 
 // ErrUnsupportedArgumentType error
@@ -41,8 +88,13 @@ func (e ErrUnsupportedArgumentType) Error() string {
 
 // Kernel represent an single kernel
 type Kernel struct {
-	d *Device
-	k pure.Kernel
+	d         *Device
+	program   *Program
+	k         pure.Kernel
+	name      string
+	args      []KernelArg                      // cached Args
+	local     map[pure.Device]*localMemory     // cached local memory limits per device
+	workGroup map[pure.Device]*workGroupLimits // cached limits of AutoLocal per device
 }
 
 // Global returns an kernel with global offsets set
@@ -93,6 +145,23 @@ func (kc KernelCall) Local(localWorkSizes ...int) KernelCall {
 	return kc
 }
 
+// Queue returns an KernelCall which runs on the queue instead of the device queue
+func (k *Kernel) Queue(q *Queue) KernelCall {
+	return KernelCall{
+		kernel:            k,
+		globalWorkOffsets: []int{},
+		globalWorkSizes:   []int{},
+		localWorkSizes:    []int{},
+		queue:             q,
+	}
+}
+
+// Queue sets the queue the KernelCall runs on instead of the device queue
+func (kc KernelCall) Queue(q *Queue) KernelCall {
+	kc.queue = q
+	return kc
+}
+
 // KernelCall is a kernel with global and local work sizes set
 // and it's ready to be run
 type KernelCall struct {
@@ -100,37 +169,73 @@ type KernelCall struct {
 	globalWorkOffsets []int
 	globalWorkSizes   []int
 	localWorkSizes    []int
+	queue             *Queue // nil means the device queue
+	checkArgs         bool
+	autoLocal         bool
 }
 
 // Run calls the kernel on its device with specified global and local work sizes and arguments
 // It's a non-blocking call, so it can return an event object that you can wait on.
 // The caller is responsible to release the returned event when it's not used anymore.
 func (kc KernelCall) Run(waitEvents []*Event, args ...interface{}) (event *Event, err error) {
-	err = kc.kernel.setArgs(args)
+	if err = require("clSetKernelArg", "clEnqueueNDRangeKernel"); err != nil {
+		return
+	}
+	if err = kc.kernel.program.check(); err != nil {
+		return
+	}
+	if kc.checkArgs {
+		if err = kc.kernel.CheckArgs(args...); err != nil {
+			return
+		}
+	}
+	queue, device := kc.kernel.d.queue, kc.kernel.d
+	if kc.queue != nil {
+		queue, device = kc.queue.queue, kc.queue.device
+	}
+	err = kc.kernel.setArgs(device, args)
 	if err != nil {
 		return
 	}
-	return kc.kernel.call(kc.globalWorkOffsets, kc.globalWorkSizes, kc.localWorkSizes, waitEvents)
+	if kc.autoLocal {
+		if kc.localWorkSizes, err = kc.kernel.autoLocal(device, kc.globalWorkSizes); err != nil {
+			return
+		}
+	}
+	return kc.kernel.call(queue, kc.globalWorkOffsets, kc.globalWorkSizes, kc.localWorkSizes, waitEvents)
 }
 
 func (k *Kernel) ReleaseKernel() error {
+	if err := require("clReleaseKernel"); err != nil {
+		return err
+	}
 	return pure.StatusToErr(pure.ReleaseKernel(k.k))
 }
 
 func (k *Kernel) Finish() error {
+	if err := require("clFinish"); err != nil {
+		return err
+	}
 	return pure.StatusToErr(pure.FinishCommandQueue(k.d.queue))
 }
 
 func (k *Kernel) Flush() error {
+	if err := require("clFlush"); err != nil {
+		return err
+	}
 	return pure.StatusToErr(pure.FlushCommandQueue(k.d.queue))
 }
 
-func newKernel(d *Device, k pure.Kernel) *Kernel {
-	kernel := &Kernel{d: d, k: k}
+func newKernel(d *Device, program *Program, k pure.Kernel, name string) *Kernel {
+	kernel := &Kernel{d: d, program: program, k: k, name: name}
 	return kernel
 }
 
-func (k *Kernel) setArgs(args []interface{}) error {
+// setArgs sets arguments of the kernel running on the device
+func (k *Kernel) setArgs(device *Device, args []interface{}) error {
+	if err := k.checkLocalMemory(device, args); err != nil {
+		return err
+	}
 	for i, arg := range args {
 		if err := k.setArg(i, arg); err != nil {
 			return err
@@ -141,19 +246,26 @@ func (k *Kernel) setArgs(args []interface{}) error {
 
 func (k *Kernel) setArg(index int, arg interface{}) error {
 	switch val := arg.(type) {
-	case float32, float64, uint8, int8, uint16, int16,
-		uint32, int32, uint64, int64:
-		return k.setArgUnsafe(index, int(unsafe.Sizeof(val)), unsafe.Pointer(&val))
 	case *Bytes:
 		return k.setArgBuffer(index, val.buf)
 	case *Vector:
 		return k.setArgBuffer(index, val.buf)
 	case *Image:
 		return k.setArgBuffer(index, val.buf)
-	//TODO case LocalBuffer:
-	//	return k.setArgLocal(index, int(val))
+	case LocalBuffer:
+		if val <= 0 {
+			return fmt.Errorf("cl: local buffer of argument %d must have positive size, got %d", index, val)
+		}
+		return k.setArgLocal(index, int(val))
 	default:
-		return ErrUnsupportedArgumentType{Index: index, Value: arg}
+		ptr, size, err := valueArg(arg)
+		if err == errUnsupportedValue {
+			return ErrUnsupportedArgumentType{Index: index, Value: arg}
+		}
+		if err != nil {
+			return fmt.Errorf("cl: argument %d: %w", index, err)
+		}
+		return k.setArgUnsafe(index, size, ptr)
 	}
 }
 
@@ -170,7 +282,7 @@ func (k *Kernel) setArgUnsafe(index, argSize int, arg unsafe.Pointer) error {
 	return pure.StatusToErr(pure.SetKernelArg(k.k, uint32(index), pure.Size(argSize), arg))
 }
 
-func (k *Kernel) call(workOffsets, workSizes, lokalSizes []int, waitEvents []*Event) (event *Event, err error) {
+func (k *Kernel) call(queue pure.CommandQueue, workOffsets, workSizes, lokalSizes []int, waitEvents []*Event) (event *Event, err error) {
 	if len(workSizes) != len(lokalSizes) && len(lokalSizes) > 0 {
 		err = errors.New("length of workSizes and localSizes differ")
 		return
@@ -200,7 +312,7 @@ func (k *Kernel) call(workOffsets, workSizes, lokalSizes []int, waitEvents []*Ev
 	}
 	event = &Event{}
 	err = pure.StatusToErr(pure.EnqueueNDRangeKernel(
-		k.d.queue,
+		queue,
 		k.k,
 		uint(uint32(len(workSizes))),
 		globalWorkOffset,
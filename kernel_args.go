@@ -0,0 +1,281 @@
+package highCL
+
+import (
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// AddressQualifier address space of kernel argument (CL_KERNEL_ARG_ADDRESS_GLOBAL, ...)
+type AddressQualifier uint32
+
+func (q AddressQualifier) String() string {
+	switch q {
+	case constants.CL_KERNEL_ARG_ADDRESS_GLOBAL:
+		return "__global"
+	case constants.CL_KERNEL_ARG_ADDRESS_LOCAL:
+		return "__local"
+	case constants.CL_KERNEL_ARG_ADDRESS_CONSTANT:
+		return "__constant"
+	case constants.CL_KERNEL_ARG_ADDRESS_PRIVATE:
+		return "__private"
+	}
+	return "address qualifier " + strconv.Itoa(int(q))
+}
+
+// AccessQualifier access of image kernel argument (CL_KERNEL_ARG_ACCESS_READ_ONLY, ...), none for other arguments
+type AccessQualifier uint32
+
+func (q AccessQualifier) String() string {
+	switch q {
+	case constants.CL_KERNEL_ARG_ACCESS_READ_ONLY:
+		return "__read_only"
+	case constants.CL_KERNEL_ARG_ACCESS_WRITE_ONLY:
+		return "__write_only"
+	case constants.CL_KERNEL_ARG_ACCESS_READ_WRITE:
+		return "__read_write"
+	case constants.CL_KERNEL_ARG_ACCESS_NONE:
+		return ""
+	}
+	return "access qualifier " + strconv.Itoa(int(q))
+}
+
+// KernelArg one argument of the kernel signature
+type KernelArg struct {
+	Name          string
+	TypeName      string // e.g. "float*", "int", "image2d_t"
+	Address       AddressQualifier
+	Access        AccessQualifier
+	TypeQualifier uint64 // bitfield of CL_KERNEL_ARG_TYPE_CONST, CL_KERNEL_ARG_TYPE_RESTRICT, ...
+}
+
+func (a KernelArg) String() string {
+	s := a.Address.String() + " " + a.TypeName + " " + a.Name
+	if access := a.Access.String(); access != "" {
+		s = access + " " + s
+	}
+	return s
+}
+
+// ErrArgumentMismatch Go argument does not match the kernel signature
+type ErrArgumentMismatch struct {
+	Index  int
+	Arg    KernelArg // zero when the kernel has less arguments
+	Reason string
+}
+
+func (e ErrArgumentMismatch) Error() string {
+	if e.Arg.TypeName == "" {
+		return fmt.Sprintf("cl: argument %d: %s", e.Index, e.Reason)
+	}
+	return fmt.Sprintf("cl: argument %d (%s): %s", e.Index, e.Arg, e.Reason)
+}
+
+// Name returns the function name of the kernel
+func (k *Kernel) Name() (string, error) {
+	if k.name != "" {
+		return k.name, nil
+	}
+	if err := require("clGetKernelInfo"); err != nil {
+		return "", err
+	}
+	if err := k.program.check(); err != nil {
+		return "", err
+	}
+	var n pure.Size
+	err := pure.StatusToErr(getKernelInfo(k.k, constants.CL_KERNEL_FUNCTION_NAME, 0, nil, &n))
+	if err != nil || n == 0 {
+		return "", err
+	}
+	name := make([]byte, n)
+	err = pure.StatusToErr(getKernelInfo(k.k, constants.CL_KERNEL_FUNCTION_NAME, n, unsafe.Pointer(&name[0]), nil))
+	return strings.TrimRight(string(name), "\x00"), err
+}
+
+// NumArgs returns the number of kernel arguments
+func (k *Kernel) NumArgs() (int, error) {
+	if err := require("clGetKernelInfo"); err != nil {
+		return 0, err
+	}
+	if err := k.program.check(); err != nil {
+		return 0, err
+	}
+	var n uint32
+	err := pure.StatusToErr(getKernelInfo(k.k, constants.CL_KERNEL_NUM_ARGS, pure.Size(unsafe.Sizeof(n)), unsafe.Pointer(&n), nil))
+	return int(n), err
+}
+
+// Args returns the kernel signature (OpenCL 1.2),
+// some drivers need the program built with BuildOptions.KernelArgInfo to report names and types
+func (k *Kernel) Args() ([]KernelArg, error) {
+	if err := k.program.check(); err != nil {
+		return nil, err
+	}
+	if k.args != nil {
+		return k.args, nil
+	}
+	if err := k.d.require("clGetKernelInfo", "clGetKernelArgInfo"); err != nil {
+		return nil, err
+	}
+	n, err := k.NumArgs()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]KernelArg, n)
+	for i := range args {
+		index := uint32(i)
+		fixed := func(param uint32, size uintptr, ptr unsafe.Pointer) error {
+			return pure.StatusToErr(getKernelArgInfo(k.k, index, param, pure.Size(size), ptr, nil))
+		}
+		str := func(param uint32) (string, error) {
+			var n pure.Size
+			if err := pure.StatusToErr(getKernelArgInfo(k.k, index, param, 0, nil, &n)); err != nil || n == 0 {
+				return "", err
+			}
+			value := make([]byte, n)
+			err := fixed(param, uintptr(n), unsafe.Pointer(&value[0]))
+			return strings.TrimRight(string(value), "\x00"), err
+		}
+		a := &args[i]
+		err = pure.ErrJoin(err, fixed(constants.CL_KERNEL_ARG_ADDRESS_QUALIFIER, unsafe.Sizeof(a.Address), unsafe.Pointer(&a.Address)))
+		err = pure.ErrJoin(err, fixed(constants.CL_KERNEL_ARG_ACCESS_QUALIFIER, unsafe.Sizeof(a.Access), unsafe.Pointer(&a.Access)))
+		err = pure.ErrJoin(err, fixed(constants.CL_KERNEL_ARG_TYPE_QUALIFIER, unsafe.Sizeof(a.TypeQualifier), unsafe.Pointer(&a.TypeQualifier)))
+		var err2 error
+		a.TypeName, err2 = str(constants.CL_KERNEL_ARG_TYPE_NAME)
+		err = pure.ErrJoin(err, err2)
+		a.Name, err2 = str(constants.CL_KERNEL_ARG_NAME)
+		err = pure.ErrJoin(err, err2)
+		if err != nil {
+			return nil, pure.ErrJoin(err, fmt.Errorf("cl: argument %d info is not available, build the program with BuildOptions.KernelArgInfo", i))
+		}
+	}
+	k.args = args
+	return args, nil
+}
+
+// CheckArgs checks Go arguments against the kernel signature, see KernelCall.CheckArgs
+func (k *Kernel) CheckArgs(args ...interface{}) error {
+	signature, err := k.Args()
+	if err != nil {
+		return err
+	}
+	return checkArgs(signature, args)
+}
+
+// CheckArgs returns KernelCall which checks Go arguments against the kernel signature in Run,
+// the mismatch is reported as ErrArgumentMismatch before the kernel is enqueued
+func (kc KernelCall) CheckArgs() KernelCall {
+	kc.checkArgs = true
+	return kc
+}
+
+// checkArgs checks Go arguments against signature
+func checkArgs(signature []KernelArg, args []interface{}) error {
+	if len(args) != len(signature) {
+		index := len(args)
+		if index > len(signature) {
+			index = len(signature)
+		}
+		return ErrArgumentMismatch{Index: index, Reason: fmt.Sprintf("kernel has %d arguments, got %d", len(signature), len(args))}
+	}
+	for i, arg := range args {
+		if reason := checkArg(signature[i], arg); reason != "" {
+			return ErrArgumentMismatch{Index: i, Arg: signature[i], Reason: reason}
+		}
+	}
+	return nil
+}
+
+// checkArg returns why arg does not match, empty when it matches
+func checkArg(signature KernelArg, arg interface{}) string {
+	typeName := strings.TrimSpace(signature.TypeName)
+	switch {
+	case strings.HasPrefix(typeName, "image"):
+		if _, ok := arg.(*Image); !ok {
+			return fmt.Sprintf("needs *Image, got %T", arg)
+		}
+	case signature.Address == constants.CL_KERNEL_ARG_ADDRESS_GLOBAL || signature.Address == constants.CL_KERNEL_ARG_ADDRESS_CONSTANT:
+		switch val := arg.(type) {
+		case *Bytes:
+		case *Vector:
+			size, _, ok := openCLTypeSize(strings.TrimSpace(strings.TrimSuffix(typeName, "*")))
+			if ok && size != val.iSize {
+				return fmt.Sprintf("points to %d bytes items, got vector of %s (%d bytes)", size, val.typ.Elem(), val.iSize)
+			}
+		default:
+			return fmt.Sprintf("needs *Bytes or *Vector, got %T", arg)
+		}
+	case signature.Address == constants.CL_KERNEL_ARG_ADDRESS_LOCAL:
+		if _, ok := arg.(LocalBuffer); !ok {
+			return fmt.Sprintf("needs LocalBuffer, got %T", arg)
+		}
+	default:
+		switch arg.(type) {
+		case *Bytes, *Vector, *Image, LocalBuffer:
+			return fmt.Sprintf("needs value, got %T", arg)
+		}
+		size, float, ok := openCLTypeSize(typeName)
+		if !ok {
+			return ""
+		}
+		t := reflect.TypeOf(arg)
+		argSize := int(t.Size())
+		switch arg.(type) {
+		case int, uint, bool:
+			argSize = 4 // passed as OpenCL int and uint
+		}
+		if argSize != size {
+			return fmt.Sprintf("needs %d bytes, got %s (%d bytes)", size, t, argSize)
+		}
+		kind := t.Kind()
+		if kind == reflect.Array {
+			kind = t.Elem().Kind()
+		}
+		if (kind == reflect.Float32 || kind == reflect.Float64) != float && kind != reflect.Struct {
+			return fmt.Sprintf("needs %s, got %s", typeName, t)
+		}
+	}
+	return ""
+}
+
+// openCLTypeSize size of OpenCL scalar or vector type (e.g. "float4"), float is true for floating point types
+func openCLTypeSize(typeName string) (size int, float bool, ok bool) {
+	base := strings.TrimRight(typeName, "0123456789")
+	n := 1
+	if base != typeName {
+		var err error
+		n, err = strconv.Atoi(typeName[len(base):])
+		if err != nil || (n != 2 && n != 3 && n != 4 && n != 8 && n != 16) {
+			return 0, false, false
+		}
+		if n == 3 {
+			n = 4 // 3-component vectors are aligned as 4-component vectors
+		}
+	}
+	switch base {
+	case "char", "uchar", "bool":
+		size = 1
+	case "short", "ushort":
+		size = 2
+	case "half":
+		size, float = 2, true
+	case "int", "uint":
+		size = 4
+	case "float":
+		size, float = 4, true
+	case "long", "ulong":
+		size = 8
+	case "double":
+		size, float = 8, true
+	default:
+		return 0, false, false
+	}
+	if base == "bool" && n > 1 {
+		return 0, false, false
+	}
+	return size * n, float, true
+}
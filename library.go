@@ -0,0 +1,181 @@
+package highCL
+
+import (
+	"errors"
+	pure "github.com/opencl-pure/pureCL"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IcdVendorsEnv environment variable with directory of ICD files or one ICD file, used instead of default ICD directories
+const IcdVendorsEnv = "OCL_ICD_VENDORS"
+
+var (
+	// handle of the OpenCL library loaded by Init, InitWithOptions or given by SetHandle, it is shared with pureCL
+	handle uintptr
+	// loadedLibrary path of the loaded OpenCL library, empty when the handle was given by SetHandle
+	loadedLibrary string
+	// loadedVersion version of functions registered from the loaded library
+	loadedVersion pure.Version
+)
+
+// InitOptions options of InitWithOptions
+type InitOptions struct {
+	// Version OpenCL version of functions to register, pure.Version2_0 when empty
+	Version pure.Version
+	// Paths tried first, in order
+	Paths []string
+	// SkipSystemPaths do not try standard library locations of the system
+	SkipSystemPaths bool
+	// SkipICD do not try libraries named by ICD files (/etc/OpenCL/vendors/*.icd)
+	SkipICD bool
+}
+
+// LoadAttempt one tried library
+type LoadAttempt struct {
+	Path   string
+	Source string // "option", "system" or the ICD file naming the library
+	Err    error  // nil for the loaded library
+}
+
+// IcdVendor installable client driver registered by ICD file
+type IcdVendor struct {
+	File    string
+	Library string
+}
+
+// InitReport diagnostics of InitWithOptions
+type InitReport struct {
+	Attempts []LoadAttempt
+	Vendors  []IcdVendor
+	Loaded   string // path of the loaded library, empty when nothing was loaded
+}
+
+// String human readable report, one line per attempt
+func (r *InitReport) String() string {
+	var sb strings.Builder
+	for _, a := range r.Attempts {
+		sb.WriteString(a.Path + " (" + a.Source + "): ")
+		if a.Err == nil {
+			sb.WriteString("loaded")
+		} else {
+			sb.WriteString(strings.ReplaceAll(a.Err.Error(), "\n", " "))
+		}
+		sb.WriteRune('\n')
+	}
+	for _, v := range r.Vendors {
+		sb.WriteString("ICD " + v.File + ": " + v.Library + "\n")
+	}
+	return sb.String()
+}
+
+// LoadedLibrary path of the OpenCL library loaded by Init or InitWithOptions
+func LoadedLibrary() string {
+	return loadedLibrary
+}
+
+// InitWithOptions tries option paths, standard system paths and libraries of ICD files,
+// first library which registers all OpenCL functions of the version is used.
+// The report tells every tried path and why it failed, it is returned also with an error
+func InitWithOptions(opts InitOptions) (*InitReport, error) {
+	version := opts.Version
+	if version == "" {
+		version = pure.Version2_0
+	}
+	report := &InitReport{}
+	if !opts.SkipICD {
+		report.Vendors = IcdVendors()
+	}
+	if handle != 0 {
+		// already loaded library (or SetHandle) is reused
+		report.Loaded = loadedLibrary
+		if version == loadedVersion {
+			return report, nil
+		}
+		if loadedLibrary != "" {
+			// pureCL closes the library when its functions fail to register, the extra reference keeps it loaded
+			_, _ = openLibrary(loadedLibrary)
+		}
+		if err := useLibrary(handle, loadedLibrary, version); err != nil {
+			report.Attempts = append(report.Attempts, LoadAttempt{Path: loadedLibrary, Source: "loaded", Err: err})
+			return report, err
+		}
+		return report, nil
+	}
+	var candidates []LoadAttempt
+	for _, path := range opts.Paths {
+		candidates = append(candidates, LoadAttempt{Path: path, Source: "option"})
+	}
+	if !opts.SkipSystemPaths {
+		for _, path := range defaultLibraryPaths() {
+			candidates = append(candidates, LoadAttempt{Path: path, Source: "system"})
+		}
+	}
+	for _, v := range report.Vendors {
+		candidates = append(candidates, LoadAttempt{Path: v.Library, Source: v.File})
+	}
+	tried := map[string]bool{}
+	for _, c := range candidates {
+		if tried[c.Path] {
+			continue
+		}
+		tried[c.Path] = true
+		h, err := openLibrary(c.Path)
+		if err == nil {
+			err = useLibrary(h, c.Path, version)
+		}
+		c.Err = err
+		report.Attempts = append(report.Attempts, c)
+		if err == nil {
+			report.Loaded = c.Path
+			return report, nil
+		}
+	}
+	return report, errors.New("no path has passed:\n" + report.String())
+}
+
+// useLibrary registers functions of the opened library h for the version and records it as the loaded library,
+// pureCL closes h on error and the library loaded before stays in use
+func useLibrary(h uintptr, path string, version pure.Version) error {
+	pure.SetHandle(h)
+	if err := pure.Init(version); err != nil {
+		pure.SetHandle(handle)
+		return err
+	}
+	handle, loadedLibrary, loadedVersion = h, path, version
+	initFunctions(h, version)
+	return nil
+}
+
+// IcdVendors lists ICD files of IcdVendorsEnv or default ICD directories with libraries they name
+func IcdVendors() []IcdVendor {
+	var files []string
+	if env := os.Getenv(IcdVendorsEnv); env != "" {
+		if info, err := os.Stat(env); err == nil && !info.IsDir() {
+			files = append(files, env)
+		} else {
+			files, _ = filepath.Glob(filepath.Join(env, "*.icd"))
+		}
+	} else {
+		for _, dir := range defaultIcdDirs() {
+			dirFiles, _ := filepath.Glob(filepath.Join(dir, "*.icd"))
+			files = append(files, dirFiles...)
+		}
+	}
+	sort.Strings(files)
+	var vendors []IcdVendor
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		library := strings.TrimSpace(string(content))
+		if library == "" {
+			continue
+		}
+		vendors = append(vendors, IcdVendor{File: f, Library: library})
+	}
+	return vendors
+}
@@ -0,0 +1,57 @@
+//go:build !windows && !wasm
+
+package highCL
+
+import (
+	"github.com/ebitengine/purego"
+	"runtime"
+)
+
+func defaultLibraryPaths() []string {
+	switch runtime.GOOS {
+	case "linux":
+		return []string{
+			"/usr/lib/libOpenCL.so",
+			"/usr/local/lib/libOpenCL.so",
+			"/usr/local/lib/libpocl.so",
+			"/usr/lib64/libOpenCL.so",
+			"/usr/lib32/libOpenCL.so",
+			"libOpenCL.so",
+			// runtime packages without development symlink
+			"/usr/lib/x86_64-linux-gnu/libOpenCL.so.1",
+			"/usr/lib/aarch64-linux-gnu/libOpenCL.so.1",
+			"/usr/lib/arm-linux-gnueabihf/libOpenCL.so.1",
+			"/usr/lib64/libOpenCL.so.1",
+			"/usr/lib/libOpenCL.so.1",
+			"/opt/rocm/lib/libOpenCL.so",
+			"/usr/local/cuda/lib64/libOpenCL.so",
+			"libOpenCL.so.1"}
+	case "darwin":
+		return []string{
+			"libOpenCL.so",
+			"/System/Library/Frameworks/OpenCL.framework/OpenCL"}
+	case "android":
+		return []string{
+			"/system/lib64/libOpenCL.so",
+			"/system/vendor/lib64/libOpenCL.so",
+			"/system/vendor/lib64/egl/libGLES_mali.so",
+			"/system/vendor/lib64/libPVROCL.so",
+			"/data/data/org.pocl.libs/files/lib64/libpocl.so",
+			"/system/lib/libOpenCL.so",
+			"/system/vendor/lib/libOpenCL.so",
+			"/system/vendor/lib/egl/libGLES_mali.so",
+			"/system/lib64/egl/libGLES_mali.so",
+			"/system/vendor/lib/libPVROCL.so",
+			"/data/data/org.pocl.libs/files/lib/libpocl.so",
+			"libOpenCL.so"}
+	}
+	return nil
+}
+
+func defaultIcdDirs() []string {
+	return []string{"/etc/OpenCL/vendors", "/usr/local/etc/OpenCL/vendors"}
+}
+
+func openLibrary(path string) (uintptr, error) {
+	return purego.Dlopen(path, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+}
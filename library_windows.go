@@ -0,0 +1,21 @@
+//go:build windows
+
+package highCL
+
+import (
+	"syscall"
+)
+
+func defaultLibraryPaths() []string {
+	return []string{"opencl.dll"}
+}
+
+// defaultIcdDirs windows registers ICDs in the registry, not in files
+func defaultIcdDirs() []string {
+	return nil
+}
+
+func openLibrary(path string) (uintptr, error) {
+	h, err := syscall.LoadLibrary(path)
+	return uintptr(h), err
+}
@@ -0,0 +1,91 @@
+package highCL
+
+import (
+	"errors"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"runtime"
+	"sort"
+	"unsafe"
+)
+
+// CompileProgram compiles source into program object without linking it (OpenCL 1.2),
+// headers maps include names used by #include in source to header sources.
+// The object is not executable, pass it to LinkPrograms, it is released by Device.Release
+func (d *Device) CompileProgram(source string, headers map[string]string, opts *BuildOptions) (*Program, error) {
+	if err := d.require("clCreateProgramWithSource", "clCompileProgram", "clGetProgramBuildInfo", "clReleaseProgram"); err != nil {
+		return nil, err
+	}
+	var ret pure.Status
+	p := pure.CreateProgramWithSource(d.ctx, 1, []string{source}, nil, &ret)
+	if err := pure.StatusToErr(ret); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// without headers both lists stay nil, the driver rejects non-NULL lists of zero headers
+	var headerPrograms []pure.Program
+	var headerNames []unsafe.Pointer
+	var err error
+	defer func() {
+		for _, h := range headerPrograms {
+			pure.ReleaseProgram(h)
+		}
+	}()
+	for _, name := range names {
+		h := pure.CreateProgramWithSource(d.ctx, 1, []string{headers[name]}, nil, &ret)
+		if err = pure.StatusToErr(ret); err != nil {
+			pure.ReleaseProgram(p)
+			return nil, err
+		}
+		headerPrograms = append(headerPrograms, h)
+		headerNames = append(headerNames, unsafe.Pointer(&append([]byte(name), '\x00')[0]))
+	}
+	ret = compileProgram(p, 1, d.id, append([]byte(opts.String()), '\x00'), uint32(len(headerPrograms)), headerPrograms, headerNames, 0, nil)
+	runtime.KeepAlive(headerNames)
+	if ret != constants.CL_SUCCESS {
+		defer pure.ReleaseProgram(p)
+		return nil, newBuildError(p, d.id, ret, opts.String())
+	}
+	return d.built(p, &d.objects), nil
+}
+
+// LinkPrograms links compiled objects and libraries into executable program (OpenCL 1.2),
+// kernels of the executable are reachable by Device.Kernel.
+// With createLibrary it links them into library which can be passed to next LinkPrograms,
+// the library is released by Device.Release
+func (d *Device) LinkPrograms(objs []*Program, opts *BuildOptions, createLibrary bool) (*Program, error) {
+	if err := d.require("clLinkProgram", "clGetProgramBuildInfo", "clReleaseProgram"); err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, errors.New("cl: nothing to link")
+	}
+	programs := make([]pure.Program, len(objs))
+	for i, o := range objs {
+		if err := o.check(); err != nil {
+			return nil, err
+		}
+		programs[i] = o.program
+	}
+	flags := opts.String()
+	if createLibrary {
+		flags += " -create-library"
+	}
+	var ret pure.Status
+	p := linkProgram(d.ctx, 1, d.id, append([]byte(flags), '\x00'), uint32(len(programs)), programs, 0, nil, &ret)
+	if ret != constants.CL_SUCCESS {
+		if p == pure.Program(0) {
+			return nil, pure.StatusToErr(ret)
+		}
+		defer pure.ReleaseProgram(p)
+		return nil, newBuildError(p, d.id, ret, flags)
+	}
+	if createLibrary {
+		return d.built(p, &d.objects), nil
+	}
+	return d.built(p, &d.programs), nil
+}
@@ -0,0 +1,63 @@
+package highCL
+
+import (
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"unsafe"
+)
+
+// LocalBuffer __local kernel argument, its value is the size in bytes,
+// the memory is allocated per work-group and it is not accessible by the host
+type LocalBuffer int
+
+// Local returns LocalBuffer for n items of T, e.g. Local[float32](256)
+func Local[T any](n int) LocalBuffer {
+	var v T
+	return LocalBuffer(n * int(unsafe.Sizeof(v)))
+}
+
+// localMemory limits of local memory of the kernel on its device
+type localMemory struct {
+	device uint64 // CL_DEVICE_LOCAL_MEM_SIZE
+	kernel uint64 // CL_KERNEL_LOCAL_MEM_SIZE before any local argument was set
+}
+
+// checkLocalMemory checks that local buffers of args fit into local memory of the device running the kernel
+// together with local memory used by the kernel itself
+func (k *Kernel) checkLocalMemory(device *Device, args []interface{}) error {
+	var total uint64
+	for _, arg := range args {
+		if l, ok := arg.(LocalBuffer); ok && l > 0 {
+			total += uint64(l)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	limits, ok := k.local[device.id[0]]
+	if !ok {
+		if err := require("clGetKernelWorkGroupInfo"); err != nil {
+			return err
+		}
+		deviceMem, err := device.GetInfoUint64(constants.CL_DEVICE_LOCAL_MEM_SIZE)
+		if err != nil {
+			return err
+		}
+		var kernel uint64
+		err = pure.StatusToErr(getKernelWorkGroupInfo(k.k, device.id[0], constants.CL_KERNEL_LOCAL_MEM_SIZE, pure.Size(unsafe.Sizeof(kernel)), unsafe.Pointer(&kernel), nil))
+		if err != nil {
+			return err
+		}
+		limits = &localMemory{device: deviceMem, kernel: kernel}
+		if k.local == nil {
+			k.local = map[pure.Device]*localMemory{}
+		}
+		k.local[device.id[0]] = limits
+	}
+	if total+limits.kernel > limits.device {
+		return fmt.Errorf("cl: local buffers need %d bytes and the kernel uses %d bytes of local memory, device has %d bytes",
+			total, limits.kernel, limits.device)
+	}
+	return nil
+}
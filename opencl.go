@@ -22,34 +22,31 @@ func GetDefaultDevice() (*Device, error) {
 
 // GetDevices returns all devices of all platforms with specified type
 func GetDevices(deviceType pure.DeviceType) ([]*Device, error) {
-	platformIds, err := getPlatforms()
+	platforms, err := GetPlatforms()
 	if err != nil {
 		return nil, err
 	}
 	var devices []*Device
-	for _, p := range platformIds {
-		var n uint32
-		err = pure.StatusToErr(pure.GetDeviceIDs(p.p, deviceType, 0, nil, &n))
+	for _, p := range platforms {
+		platformDevices, err := p.Devices(deviceType)
 		if err != nil {
-			return nil, err
-		}
-		deviceIds := make([]pure.Device, int(n))
-		err = pure.StatusToErr(pure.GetDeviceIDs(p.p, deviceType, n, deviceIds, nil))
-		if err != nil {
-			return nil, err
-		}
-		for _, d := range deviceIds {
-			device, err := newDevice([]pure.Device{d}, p)
-			if err != nil {
-				return nil, err
+			for _, d := range devices {
+				err = pure.ErrJoin(err, d.Release())
 			}
-			devices = append(devices, device)
+			return nil, err
 		}
+		devices = append(devices, platformDevices...)
+	}
+	if len(devices) == 0 {
+		return nil, pure.StatusToErr(constants.CL_DEVICE_NOT_FOUND)
 	}
 	return devices, nil
 }
 
 func newDevice(id []pure.Device, p *Platform) (*Device, error) {
+	if err := require("clCreateContext", "clReleaseContext"); err != nil {
+		return nil, err
+	}
 	d := &Device{id: id, platform: p}
 	var ret pure.Status
 	d.ctx = pure.CreateContext(nil, 1, id, nil, nil, &ret)
@@ -60,21 +57,46 @@ func newDevice(id []pure.Device, p *Platform) (*Device, error) {
 	if d.ctx == pure.Context(0) {
 		return nil, ErrUnknown
 	}
-	if pure.CreateCommandQueueWithProperties != nil {
-		d.queue = pure.CreateCommandQueueWithProperties(d.ctx, d.id[0], 0, &ret)
-	} else {
-		d.queue = pure.CreateCommandQueue(d.ctx, d.id[0], 0, &ret)
-	}
-	if err = pure.StatusToErr(ret); err != nil {
-		return nil, err
+	d.queue, err = newQueue(d)
+	if err != nil {
+		return nil, pure.ErrJoin(err, pure.StatusToErr(pure.ReleaseContext(d.ctx)))
 	}
 	return d, nil
 }
 
-func SetHandle(handle uintptr) {
-	pure.SetHandle(handle)
+func newQueue(d *Device) (pure.CommandQueue, error) {
+	return newQueueWithProperties(d, 0)
+}
+
+// newQueueWithProperties creates queue on d.ctx and d.id[0],
+// clCreateCommandQueueWithProperties is used only when it is loaded and the device implements OpenCL 2.0
+func newQueueWithProperties(d *Device, properties uint64) (pure.CommandQueue, error) {
+	var ret pure.Status
+	var queue pure.CommandQueue
+	if d.Supports("clCreateCommandQueueWithProperties") {
+		var list []uint64
+		if properties != 0 {
+			list = []uint64{constants.CL_QUEUE_PROPERTIES, properties, 0}
+		}
+		queue = createCommandQueueWithProperties(d.ctx, d.id[0], list, &ret)
+	} else {
+		if err := require("clCreateCommandQueue"); err != nil {
+			return 0, err
+		}
+		queue = pure.CreateCommandQueue(d.ctx, d.id[0], pure.CommandQueueProperty(properties), &ret)
+	}
+	return queue, pure.StatusToErr(ret)
+}
+
+// SetHandle uses already opened OpenCL library, Init must be called after it
+func SetHandle(h uintptr) {
+	handle, loadedLibrary, loadedVersion = h, "", ""
+	pure.SetHandle(h)
 }
 
+// Init loads OpenCL library from paths, default system paths or ICD files and registers its functions for the version,
+// already loaded library is reused, use InitWithOptions to see why libraries failed to load
 func Init(version pure.Version, paths ...string) error {
-	return pure.Init(version, paths...)
+	_, err := InitWithOptions(InitOptions{Version: version, Paths: paths})
+	return err
 }
@@ -11,7 +11,13 @@ import (
 	_ "image/png"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+	"unsafe"
 )
 
 func TestGetDevices(t *testing.T) {
@@ -67,6 +73,36 @@ func TestGetDevices(t *testing.T) {
 	}
 }
 
+func TestDeviceInfo(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	info, err := d.Info()
+	if err != nil {
+		t.Log(err) // older devices do not know every parameter
+	}
+	name, err := d.Name()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != name {
+		t.Fatal("info name not equal to device name")
+	}
+	if info.MaxWorkGroupSize == 0 || info.MaxComputeUnits == 0 || info.GlobalMemSize == 0 {
+		t.Fatal("device limits not filled")
+	}
+	if len(info.MaxWorkItemSizes) != int(info.MaxWorkItemDimensions) {
+		t.Fatal("max work item sizes not equal to dimensions")
+	}
+	t.Logf("%+v", info)
+}
+
 func TestBytes(t *testing.T) {
 	err := Init(pure.Version2_0)
 	if err != nil {
@@ -759,3 +795,1454 @@ func BenchmarkKernelBuildOptionsNil(t *testing.B) {
 		t.Fatal(err)
 	}
 }
+
+func TestSelectDeviceRanking(t *testing.T) {
+	candidates := []deviceCandidate{
+		{index: 0, info: &DeviceInfo{Type: constants.CL_DEVICE_TYPE_CPU, Name: "pthread-cpu", Vendor: "pocl", Version: "OpenCL 3.0 pocl", MaxComputeUnits: 16, GlobalMemSize: 1 << 34}},
+		{index: 1, info: &DeviceInfo{Type: constants.CL_DEVICE_TYPE_GPU, Name: "GeForce GTX 1050", Vendor: "NVIDIA Corporation", Version: "OpenCL 1.2 CUDA", Extensions: "cl_khr_fp64 cl_khr_icd", MaxComputeUnits: 5, GlobalMemSize: 1 << 31}},
+		{index: 2, info: &DeviceInfo{Type: constants.CL_DEVICE_TYPE_GPU, Name: "Intel UHD 620", Vendor: "Intel", Version: "OpenCL 3.0 NEO", MaxComputeUnits: 24, GlobalMemSize: 1 << 30}, platformName: "Intel(R) OpenCL HD Graphics"},
+	}
+	tests := []struct {
+		criteria DeviceCriteria
+		want     int
+	}{
+		{DeviceCriteria{}, 2},
+		{DeviceCriteria{Type: constants.CL_DEVICE_TYPE_CPU}, 0},
+		{DeviceCriteria{Vendor: "nvidia"}, 1},
+		{DeviceCriteria{MinGlobalMemSize: 1 << 31, Type: constants.CL_DEVICE_TYPE_GPU}, 1},
+		{DeviceCriteria{Extensions: []pure.Extension{pure.Extension_khr_fp64}}, 1},
+		{DeviceCriteria{MinVersion: pure.Version2_0}, 2},
+	}
+	for _, test := range tests {
+		i, err := selectByCriteria(candidates, test.criteria)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != test.want {
+			t.Errorf("criteria %+v selected %d, want %d", test.criteria, i, test.want)
+		}
+	}
+	if _, err := selectByCriteria(candidates, DeviceCriteria{Name: "meh"}); !errors.Is(err, ErrNoDevice) {
+		t.Fatal("selected not existing device")
+	}
+	envs := map[string]int{"0": 0, "cpu": 0, "GPU": 2, "geforce": 1, "hd graphics": 2}
+	for env, want := range envs {
+		i, err := selectByEnv(candidates, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != want {
+			t.Errorf("%s=%s selected %d, want %d", DeviceEnv, env, i, want)
+		}
+	}
+	if _, err := selectByEnv(candidates, "3"); err == nil {
+		t.Fatal("selected device out of range")
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	platforms, err := GetPlatforms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(platforms) == 0 {
+		t.Fatal("no platform")
+	}
+	for _, p := range platforms {
+		info, err := p.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(info.Name, info.Vendor, info.Version)
+		devices, err := p.Devices(constants.CL_DEVICE_TYPE_ALL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range devices {
+			name, _ := d.Name()
+			platformName, _ := d.PlatformName()
+			if platformName != info.Name {
+				t.Error("device platform not equal to listed platform")
+			}
+			t.Log("\t", name)
+			if err = d.Release(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	platforms, err := GetPlatforms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices, err := platforms[0].Devices(constants.CL_DEVICE_TYPE_ALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, d := range devices {
+			_ = d.Release()
+		}
+	}()
+	c, err := NewContext(devices...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Release()
+	_, err = c.AddProgram(testKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	v, err := c.Device(0).NewVector(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	for _, d := range c.Devices() {
+		k, err := d.Kernel("testKernel")
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := k.Global(16).Local(1).Run(nil, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = k.Finish()
+		_ = event.Release()
+		_ = k.ReleaseKernel()
+	}
+	receivedData, err := v.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slice := receivedData.Interface().([]float32)
+	for i := range data {
+		if data[i]+float32(len(c.Devices())) != slice[i] {
+			t.Error("receivedData not equal to data")
+		}
+	}
+	// shared programs outlive released context devices, Context.Release skips them
+	if len(c.Devices()) > 1 {
+		if err = c.Device(0).Release(); err != nil {
+			t.Fatal(err)
+		}
+		if err = c.Device(0).Release(); !errors.Is(err, ErrDeviceReleased) {
+			t.Fatal("expected ErrDeviceReleased, got", err)
+		}
+		p := c.programs[0]
+		k, err := p.Kernel("testKernel")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.d != c.Device(1) {
+			t.Fatal("kernel of shared program runs on released device")
+		}
+		_ = k.ReleaseKernel()
+	}
+}
+
+func TestQueue(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	q, err := d.NewQueue(QueueOptions{Profiling: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Release()
+	_, err = d.AddProgram(testKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("testKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	data := []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	v, err := d.NewVector(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	if err = <-v.ResetOn(q, data); err != nil {
+		t.Fatal(err)
+	}
+	event, err := k.Global(16).Local(1).Queue(q).Run(nil, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer event.Release()
+	if err = event.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := event.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.End < profile.Start {
+		t.Fatal("kernel ended before start")
+	}
+	t.Log(profile.Duration())
+	receivedData, err := v.DataOn(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slice := receivedData.Interface().([]float32)
+	for i := range data {
+		if data[i]+1 != slice[i] {
+			t.Error("receivedData not equal to data")
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := GetDevices(constants.CL_DEVICE_TYPE_CPU)
+	if err != nil {
+		t.Skip("no CPU device:", err)
+	}
+	d := ds[0]
+	defer d.Release()
+	maxSubDevices, err := d.GetInfoUint32(constants.CL_DEVICE_PARTITION_MAX_SUB_DEVICES)
+	if err != nil || maxSubDevices < 2 {
+		t.Skip("device can not be partitioned")
+	}
+	computeUnits, err := d.GetInfoUint32(constants.CL_DEVICE_MAX_COMPUTE_UNITS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subDevices, err := d.Partition(PartitionByCounts(int(computeUnits) - 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subDevices) != 1 || subDevices[0].Parent() != d {
+		t.Fatal("bad sub-devices")
+	}
+	units, err := subDevices[0].GetInfoUint32(constants.CL_DEVICE_MAX_COMPUTE_UNITS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if units != computeUnits-1 {
+		t.Fatal("sub-device has wrong count of compute units")
+	}
+	if err = subDevices[0].Release(); err != nil {
+		t.Fatal(err)
+	}
+	subDevices, err = d.Partition(PartitionEqually(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(len(subDevices), "sub-devices")
+	// sub-devices are released with the parent
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]Version{
+		"OpenCL 1.2 CUDA 12.0.89": {Major: 1, Minor: 2, Vendor: "CUDA 12.0.89"},
+		"OpenCL C 2.0 ":           {Major: 2, Minor: 0},
+		"OpenCL 3.0 PoCL 5.0+debian  Linux, None+Asserts, RELOC, SPIR, LLVM 16.0.6, SLEEF, DISTRO, POCL_DEBUG": {Major: 3, Minor: 0, Vendor: "PoCL 5.0+debian  Linux, None+Asserts, RELOC, SPIR, LLVM 16.0.6, SLEEF, DISTRO, POCL_DEBUG"},
+		string(pure.Version1_1): {Major: 1, Minor: 1},
+	}
+	for text, want := range tests {
+		v, err := ParseVersion(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != want {
+			t.Errorf("%q parsed as %+v, want %+v", text, v, want)
+		}
+	}
+	for _, text := range []string{"", "OpenCL", "OpenCL x.1", "OpenCL 2"} {
+		if _, err := ParseVersion(text); err == nil {
+			t.Errorf("%q parsed without error", text)
+		}
+	}
+	v := Version{Major: 2, Minor: 1}
+	if !v.AtLeast(2, 0) || !v.AtLeast(1, 2) || v.AtLeast(3, 0) || v.Compare(Version{Major: 2, Minor: 1, Vendor: "x"}) != 0 {
+		t.Fatal("bad version comparison")
+	}
+	set := NewExtensionSet("cl_khr_icd  cl_khr_fp64 cl_khr_int64_base_atomics ")
+	if !set.Has(pure.Extension_khr_fp64) || set.Has(pure.Extension_khr_gl_sharing) || len(set.Slice()) != 3 {
+		t.Fatal("bad extension set")
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	if Loaded("clMeh") {
+		t.Fatal("not existing function is loaded")
+	}
+	var notSupported ErrNotSupported
+	if err := require("clMeh"); !errors.As(err, &notSupported) || notSupported.Feature != "clMeh" {
+		t.Fatal("expected ErrNotSupported, got", err)
+	}
+	d := &Device{version: &Version{Major: 1, Minor: 1}}
+	if d.Supports("clCreateSubDevices") {
+		t.Fatal("OpenCL 1.1 device supports sub-devices")
+	}
+	_, err := d.Partition(PartitionEqually(1))
+	if !errors.As(err, &notSupported) || notSupported.Feature != "clCreateSubDevices" {
+		t.Fatal("expected ErrNotSupported, got", err)
+	}
+	t.Log(err)
+	_, err = d.AddProgramIL([]byte{0x03, 0x02, 0x23, 0x07}, nil)
+	if !errors.As(err, &notSupported) || notSupported.Feature != "clCreateProgramWithIL" {
+		t.Fatal("expected ErrNotSupported, got", err)
+	}
+	for name, loaded := range LoadedFunctions() {
+		t.Log(name, loaded)
+	}
+}
+
+func TestInitWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	icd := dir + "/meh.icd"
+	if err := os.WriteFile(icd, []byte("libMehOpenCL.so\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(IcdVendorsEnv, dir)
+	vendors := IcdVendors()
+	if len(vendors) != 1 || vendors[0].File != icd || vendors[0].Library != "libMehOpenCL.so" {
+		t.Fatalf("bad ICD vendors %+v", vendors)
+	}
+	meh := InitOptions{Version: pure.Version2_0, Paths: []string{"/meh/libOpenCL.so"}, SkipSystemPaths: true}
+	if handle == 0 {
+		report, err := InitWithOptions(meh)
+		if err == nil {
+			t.Fatal("not existing library loaded")
+		}
+		if len(report.Attempts) != 2 || report.Attempts[0].Source != "option" || report.Attempts[1].Source != icd || report.Loaded != "" {
+			t.Fatalf("bad report %+v", report)
+		}
+		t.Log(report)
+	}
+	t.Setenv(IcdVendorsEnv, "") // default ICD directories
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := LoadedLibrary()
+	if loaded == "" {
+		t.Fatal("library loaded by Init not reported")
+	}
+	// loaded library is reused, options are not tried
+	report, err := InitWithOptions(meh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != loaded || LoadedLibrary() != loaded || len(report.Attempts) != 0 {
+		t.Fatalf("loaded library not reused %+v", report)
+	}
+	if _, err = GetPlatforms(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProgramCache(t *testing.T) {
+	key := programCacheKey([]string{"ab", "c"}, "", []string{"gpu"})
+	if key == programCacheKey([]string{"a", "bc"}, "", []string{"gpu"}) {
+		t.Fatal("source boundaries are not part of the key")
+	}
+	if key == programCacheKey([]string{"ab", "c"}, "-cl-fast-relaxed-math", []string{"gpu"}) {
+		t.Fatal("flags are not part of the key")
+	}
+	if key == programCacheKey([]string{"ab", "c"}, "", []string{"cpu"}) {
+		t.Fatal("device is not part of the key")
+	}
+	picked, err := binariesForDevices([]pure.Device{1, 2, 3}, [][]byte{{1}, {}, {3}}, []pure.Device{3, 1})
+	if err != nil || !reflect.DeepEqual(picked, [][]byte{{3}, {1}}) {
+		t.Fatal("bad binaries of devices", picked, err)
+	}
+	if _, err = binariesForDevices([]pure.Device{1}, [][]byte{{1}}, []pure.Device{2}); err == nil {
+		t.Fatal("binary of not associated device was picked")
+	}
+	cache, err := NewProgramCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		d, err := GetDefaultDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.SetProgramCache(cache)
+		_, err = d.AddProgram(testKernel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k, err := d.Kernel("testKernel")
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.ReleaseKernel()
+		if err = d.Release(); err != nil {
+			t.Fatal(err)
+		}
+		files, _ := filepath.Glob(filepath.Join(cache.Dir(), "*.bin"))
+		if len(files) != 1 {
+			t.Fatal("expected one cached binary, got", files)
+		}
+	}
+	if err = cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	// device of a shared context builds program created for the whole context
+	for i := 0; i < 2; i++ {
+		d, err := GetDefaultDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewContext(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetProgramCache(cache)
+		if _, err = c.Device(0).AddProgram(testKernel); err != nil {
+			t.Fatal(err)
+		}
+		k, err := c.Device(0).Kernel("testKernel")
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.ReleaseKernel()
+		if err = pure.ErrJoin(c.Release(), d.Release()); err != nil {
+			t.Fatal(err)
+		}
+		files, _ := filepath.Glob(filepath.Join(cache.Dir(), "*.bin"))
+		if len(files) != 1 {
+			t.Fatal("expected one cached binary of the context device, got", files)
+		}
+	}
+	if err = cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProgramFromBinary(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	p, err := d.AddProgram(testKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaries, err := p.GetBinaries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(binaries) != 1 || len(binaries[0]) == 0 {
+		t.Fatal("expected one binary")
+	}
+	d2, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d2.Release()
+	p2, status, err := d2.AddProgramFromBinary(binaries, nil)
+	if err != nil {
+		t.Fatal(err, status)
+	}
+	// sizes and pointers of clGetProgramInfo are size_t and pointers of the host, 8 bytes on 64-bit hosts
+	binaries2, err := p2.GetBinaries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(binaries2) != 1 || len(binaries2[0]) == 0 {
+		t.Fatal("expected one binary of program loaded from binary")
+	}
+	k, err := d2.Kernel("testKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ReleaseKernel()
+	_, _, err = d2.AddProgramFromBinary([][]byte{[]byte("not a binary")}, nil)
+	if err == nil {
+		t.Fatal("invalid binary was accepted")
+	}
+}
+
+// spirvKernel assembles SPIR-V 1.0 module with empty kernel name(__global float*),
+// addressBits selects Physical32 or Physical64 addressing model
+func spirvKernel(name string, addressBits uint32) []byte {
+	op := func(opcode uint32, operands ...uint32) []uint32 {
+		return append([]uint32{uint32(len(operands)+1)<<16 | opcode}, operands...)
+	}
+	literal := make([]uint32, len(name)/4+1) // zero terminated and padded
+	for i := 0; i < len(name); i++ {
+		literal[i/4] |= uint32(name[i]) << (8 * (i % 4))
+	}
+	addressing := uint32(2) // Physical64
+	if addressBits == 32 {
+		addressing = 1
+	}
+	const void, float, ptr, fnType, fn, param, label = 1, 2, 3, 4, 5, 6, 7
+	words := []uint32{0x07230203, 0x00010000, 0, 8, 0}
+	words = append(words, op(17, 4)...)                                      // OpCapability Addresses
+	words = append(words, op(17, 6)...)                                      // OpCapability Kernel
+	words = append(words, op(14, addressing, 2)...)                          // OpMemoryModel OpenCL
+	words = append(words, op(15, append([]uint32{6, fn}, literal...)...)...) // OpEntryPoint Kernel
+	words = append(words, op(19, void)...)                                   // OpTypeVoid
+	words = append(words, op(22, float, 32)...)                              // OpTypeFloat
+	words = append(words, op(32, ptr, 5, float)...)                          // OpTypePointer CrossWorkgroup
+	words = append(words, op(33, fnType, void, ptr)...)                      // OpTypeFunction
+	words = append(words, op(54, void, fn, 0, fnType)...)                    // OpFunction
+	words = append(words, op(55, ptr, param)...)                             // OpFunctionParameter
+	words = append(words, op(248, label)...)                                 // OpLabel
+	words = append(words, op(253)...)                                        // OpReturn
+	words = append(words, op(56)...)                                         // OpFunctionEnd
+	il := make([]byte, 4*len(words))
+	for i, w := range words {
+		*(*uint32)(unsafe.Pointer(&il[4*i])) = w // SPIR-V words are in host byte order
+	}
+	return il
+}
+
+func TestProgramIL(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	version, err := d.ILVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(version, "SPIR-V") {
+		t.Skip("device does not support SPIR-V:", version)
+	}
+	addressBits, err := d.GetInfoUint32(constants.CL_DEVICE_ADDRESS_BITS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := d.AddProgramIL(spirvKernel("ilKernel", addressBits), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("ilKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	names, err := p.KernelNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"ilKernel"}) {
+		t.Fatal("expected ilKernel, got", names)
+	}
+}
+
+func TestCompileAndLink(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	headers := map[string]string{"square.h": "float square(float x);\n"}
+	lib, err := d.CompileProgram("float square(float x) { return x * x; }\n", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib, err = d.LinkPrograms([]*Program{lib}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := d.CompileProgram(`#include "square.h"
+__kernel void squareKernel(__global float* a) { int i = get_global_id(0); a[i] = square(a[i]); }
+`, headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.LinkPrograms([]*Program{obj, lib}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("squareKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	v, err := d.NewVector([]float32{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	event, err := k.Global(4).Local(1).Run(nil, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := v.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = event.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if data.Index(3).Float() != 16 {
+		t.Fatal("expected 16, got", data.Index(3).Float())
+	}
+}
+
+func TestBuildOptionsString(t *testing.T) {
+	op := &BuildOptions{
+		WarningsAsErrors:  true,
+		Macros:            map[string]string{"N": "16", "DEBUG": "", "MSG": `say "hi" now`},
+		DirectoryIncludes: []string{"/usr/include/cl", "C:\\Program Files\\kernels"},
+		Version:           pure.Version1_2,
+		Debug:             true,
+		DenormsAreZero:    true,
+		FiniteMathOnly:    true,
+		KernelArgInfo:     true,
+	}
+	flags := op.String()
+	expected := `-D DEBUG -D "MSG=say \"hi\" now" -D N=16 -I /usr/include/cl -I "C:\\Program Files\\kernels" ` +
+		`-cl-std=CL1.2 -Werror -g -cl-denorms-are-zero -cl-finite-math-only -cl-kernel-arg-info `
+	if flags != expected {
+		t.Fatalf("expected %s, got %s", expected, flags)
+	}
+	parsed, err := ParseBuildOptions(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(op, parsed) {
+		t.Fatalf("expected %+v, got %+v", op, parsed)
+	}
+	parsed, err = ParseBuildOptions("-DN=4 -I'include dir' -cl-mad-enable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Macros["N"] != "4" || parsed.DirectoryIncludes[0] != "include dir" || !parsed.MadEnable {
+		t.Fatalf("unexpected %+v", parsed)
+	}
+	if _, err = ParseBuildOptions("-cl-meh"); err == nil {
+		t.Fatal("unknown option was parsed")
+	}
+	if _, err = ParseBuildOptions(`-D "N=4`); err == nil {
+		t.Fatal("unterminated quote was parsed")
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	log := "<kernel>:3:12: error: use of undeclared identifier 'b'\n" +
+		"    a[i] = b;\n" +
+		"           ^\n" +
+		"<kernel>:5:1: warning: unused variable 'c'\n" +
+		"kernels/blur.cl:7: fatal error: 'common.h' file not found\n"
+	diagnostics := ParseDiagnostics(log)
+	expected := []Diagnostic{
+		{File: "<kernel>", Line: 3, Column: 12, Severity: "error", Message: "use of undeclared identifier 'b'"},
+		{File: "<kernel>", Line: 5, Column: 1, Severity: "warning", Message: "unused variable 'c'"},
+		{File: "kernels/blur.cl", Line: 7, Severity: "fatal error", Message: "'common.h' file not found"},
+	}
+	if !reflect.DeepEqual(diagnostics, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, diagnostics)
+	}
+	if diagnostics[2].String() != "kernels/blur.cl:7: fatal error: 'common.h' file not found" {
+		t.Fatal("unexpected", diagnostics[2].String())
+	}
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	_, err = d.AddProgram("__kernel void bad(__global float* a) { a[0] = b; }")
+	var buildError *BuildError
+	if !errors.As(err, &buildError) {
+		t.Fatal("expected BuildError, got", err)
+	}
+	if buildError.Status != constants.CL_BUILD_PROGRAM_FAILURE || len(buildError.Logs) != 1 {
+		t.Fatal("unexpected", buildError)
+	}
+	for _, diagnostic := range buildError.Diagnostics() {
+		t.Log(diagnostic)
+	}
+}
+
+func TestBuildLog(t *testing.T) {
+	if BuildStatus(constants.CL_BUILD_IN_PROGRESS).String() != "in progress" {
+		t.Fatal("unexpected", BuildStatus(constants.CL_BUILD_IN_PROGRESS))
+	}
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	d.SetBuildLogHook(func(p *Program, logs []DeviceBuildLog) {
+		for _, l := range logs {
+			t.Log(l.Device, l.Log)
+		}
+	})
+	p, err := d.AddProgram("__kernel void unused(__global float* a) { int b = 1; a[0] = 1.0; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := p.BuildStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || status[0].Status != constants.CL_BUILD_SUCCESS {
+		t.Fatal("unexpected build status", status)
+	}
+	logs, err := p.BuildLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Fatal("expected one log, got", len(logs))
+	}
+}
+
+func TestProgramKernel(t *testing.T) {
+	names := splitKernelNames("blur;sharpen;\x00")
+	if !reflect.DeepEqual(names, []string{"blur", "sharpen"}) {
+		t.Fatal("unexpected", names)
+	}
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	source := "__kernel void scale(__global float* a) { a[get_global_id(0)] *= FACTOR; }\n"
+	twice, err := d.AddProgramWithOptions([]string{source}, &BuildOptions{Macros: map[string]string{"FACTOR": "2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.AddProgramWithOptions([]string{source}, &BuildOptions{Macros: map[string]string{"FACTOR": "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ambiguous ErrAmbiguousKernel
+	if _, err = d.Kernel("scale"); !errors.As(err, &ambiguous) || ambiguous.Programs != 2 {
+		t.Fatal("expected ErrAmbiguousKernel, got", err)
+	}
+	var unknown ErrUnknownKernel
+	if _, err = d.Kernel("meh"); !errors.As(err, &unknown) {
+		t.Fatal("expected ErrUnknownKernel, got", err)
+	}
+	if _, err = twice.Kernel("meh"); !errors.As(err, &unknown) {
+		t.Fatal("expected ErrUnknownKernel, got", err)
+	}
+	names, err = twice.KernelNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"scale"}) {
+		t.Fatal("unexpected", names)
+	}
+	k, err := twice.Kernel("scale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	v, err := d.NewVector([]float32{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	event, err := k.Global(2).Local(1).Run(nil, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer event.Release()
+	data, err := v.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Index(1).Float() != 4 {
+		t.Fatal("expected 4, got", data.Index(1).Float())
+	}
+}
+
+func TestProgramRelease(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	p, err := d.AddProgram(testKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := p.Kernel("testKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	p2, err := d.ReplaceProgram(p, []string{testKernel}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Released() || p2.Released() || len(d.programs) != 1 {
+		t.Fatal("old program was not replaced")
+	}
+	v, err := d.NewVector([]float32{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	if _, err = k.Global(2).Local(1).Run(nil, v); !errors.Is(err, ErrProgramReleased) {
+		t.Fatal("expected ErrProgramReleased, got", err)
+	}
+	k.name = "" // not cached
+	if _, err = k.Name(); !errors.Is(err, ErrProgramReleased) {
+		t.Fatal("Name: expected ErrProgramReleased, got", err)
+	}
+	if _, err = k.Args(); !errors.Is(err, ErrProgramReleased) {
+		t.Fatal("Args: expected ErrProgramReleased, got", err)
+	}
+	if _, err = k.WorkGroupInfo(nil); !errors.Is(err, ErrProgramReleased) {
+		t.Fatal("WorkGroupInfo: expected ErrProgramReleased, got", err)
+	}
+	tuner, err := NewTuner(filepath.Join(t.TempDir(), "tuning.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tuner.Tune(k.Global(2), nil, v); !errors.Is(err, ErrProgramReleased) {
+		t.Fatal("Tune: expected ErrProgramReleased, got", err)
+	}
+	if err = p.Release(); !errors.Is(err, ErrProgramReleased) {
+		t.Fatal("expected ErrProgramReleased, got", err)
+	}
+	k2, err := d.Kernel("testKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k2.ReleaseKernel()
+	// concurrent releases release the program once
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() { errs <- p2.Release() }()
+	}
+	released := 0
+	for i := 0; i < cap(errs); i++ {
+		if err = <-errs; err == nil {
+			released++
+		} else if !errors.Is(err, ErrProgramReleased) {
+			t.Fatal(err)
+		}
+	}
+	if released != 1 {
+		t.Fatal("program released", released, "times")
+	}
+	if len(d.programs) != 0 {
+		t.Fatal("released program stays in the device")
+	}
+}
+
+func TestResolveIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"kernels/main.cl":    {Data: []byte("#include \"common.h\"\n#include <math.h>\n#include \"common.h\"\n__kernel void k() {}\n")},
+		"kernels/common.h":   {Data: []byte("#pragma once\n#include \"types.h\"\n")},
+		"include/types.h":    {Data: []byte("typedef float real;\n")},
+		"kernels/cycle.cl":   {Data: []byte("#include \"cycle.h\"\n")},
+		"kernels/cycle.h":    {Data: []byte("#include \"cycle.cl\"\n")},
+		"kernels/missing.cl": {Data: []byte("#include \"missing.h\"\n")},
+	}
+	source, err := ResolveIncludes(fsys, "kernels/main.cl", []string{"include"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#line 1 "kernels/main.cl"
+#line 1 "kernels/common.h"
+
+#line 1 "include/types.h"
+typedef float real;
+#line 3 "kernels/common.h"
+#line 2 "kernels/main.cl"
+#include <math.h>
+
+__kernel void k() {}
+`
+	if source != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, source)
+	}
+	if _, err = ResolveIncludes(fsys, "kernels/cycle.cl", nil); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatal("expected include cycle, got", err)
+	}
+	if _, err = ResolveIncludes(fsys, "kernels/missing.cl", nil); err == nil {
+		t.Fatal("missing header was resolved")
+	}
+	if name := lineFileName(`jádro\"a".cl`); name != `"jádro\\\"a\".cl"` {
+		t.Fatal("bad #line file name", name)
+	}
+	err = Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	fsys["kernels/bad.cl"] = &fstest.MapFile{Data: []byte("#include \"common.h\"\n__kernel void bad() { real x = ; }\n")}
+	_, err = d.AddProgramFS(fsys, "kernels/bad.cl", nil, "include")
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatal("expected build error, got", err)
+	}
+	if strings.Contains(buildErr.Options, "-I") {
+		t.Fatal("directories of the file system were passed to the compiler:", buildErr.Options)
+	}
+}
+
+func TestAddProgramAsync(t *testing.T) {
+	failed := func(err error) <-chan BuildResult {
+		res := make(chan BuildResult, 1)
+		res <- BuildResult{Err: err}
+		close(res)
+		return res
+	}
+	first := &BuildError{Status: constants.CL_BUILD_PROGRAM_FAILURE, Options: "-DFIRST"}
+	second := &BuildError{Status: constants.CL_BUILD_PROGRAM_FAILURE, Options: "-DSECOND"}
+	_, err := WaitPrograms(failed(first), failed(nil), failed(second))
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr != first || !errors.Is(err, second) {
+		t.Fatal("build errors are not reachable from", err)
+	}
+	err = Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	firstBuild := d.AddProgramAsync([]string{testKernel}, nil)
+	secondBuild := d.AddProgramAsync([]string{"__kernel void other(__global float* a) { a[0] = 1.0f; }"}, nil)
+	bad := d.AddProgramAsync([]string{"meh"}, nil)
+	programs, err := WaitPrograms(firstBuild, secondBuild)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(programs) != 2 || len(d.programs) != 2 {
+		t.Fatal("expected two programs")
+	}
+	if result := <-bad; result.Err == nil || result.Program != nil {
+		t.Fatal("bad program was built")
+	}
+	k, err := d.Kernel("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ReleaseKernel()
+	// run with -race: builds, settings and kernel lookups of one device run concurrently
+	cache, err := NewProgramCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var builds []<-chan BuildResult
+	for i := 0; i < 8; i++ {
+		source := fmt.Sprintf("__kernel void concurrent%d(__global float* a) { a[0] = %d.0f; }", i, i)
+		builds = append(builds, d.AddProgramAsync([]string{source}, nil))
+		if i%2 == 0 {
+			d.SetBuildLogHook(func(p *Program, logs []DeviceBuildLog) {})
+			d.SetProgramCache(cache)
+		} else {
+			d.SetBuildLogHook(nil)
+			d.SetProgramCache(nil)
+		}
+		if k, err := d.Kernel("other"); err == nil {
+			k.ReleaseKernel()
+		}
+	}
+	if _, err = WaitPrograms(builds...); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.programs) != 10 {
+		t.Fatal("expected 10 programs, got", len(d.programs))
+	}
+	for i := 0; i < 8; i++ {
+		k, err := d.Kernel(fmt.Sprintf("concurrent%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.ReleaseKernel()
+	}
+}
+
+func TestCheckArgs(t *testing.T) {
+	signature := []KernelArg{
+		{Name: "a", TypeName: "float*", Address: constants.CL_KERNEL_ARG_ADDRESS_GLOBAL, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+		{Name: "n", TypeName: "int", Address: constants.CL_KERNEL_ARG_ADDRESS_PRIVATE, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+		{Name: "scale", TypeName: "float", Address: constants.CL_KERNEL_ARG_ADDRESS_PRIVATE, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+	}
+	floats := &Vector{iSize: 4, typ: reflect.TypeOf([]float32{})}
+	doubles := &Vector{iSize: 8, typ: reflect.TypeOf([]float64{})}
+	if err := checkArgs(signature, []interface{}{floats, int32(1), float32(2)}); err != nil {
+		t.Fatal(err)
+	}
+	var mismatch ErrArgumentMismatch
+	for i, args := range [][]interface{}{
+		{floats, int32(1)},
+		{doubles, int32(1), float32(2)},
+		{floats, int64(1), float32(2)},
+		{floats, int32(1), int32(2)},
+		{int32(1), floats, float32(2)},
+	} {
+		err := checkArgs(signature, args)
+		if !errors.As(err, &mismatch) {
+			t.Fatal(i, "expected ErrArgumentMismatch, got", err)
+		}
+		t.Log(err)
+	}
+	if size, float, ok := openCLTypeSize("float3"); !ok || !float || size != 16 {
+		t.Fatal("unexpected float3 size", size)
+	}
+}
+
+const reductionKernel = `
+__kernel void sum(__global const float* in, __global float* out, __local float* scratch) {
+	int lid = get_local_id(0);
+	scratch[lid] = in[get_global_id(0)];
+	barrier(CLK_LOCAL_MEM_FENCE);
+	for (int s = get_local_size(0) / 2; s > 0; s >>= 1) {
+		if (lid < s) {
+			scratch[lid] += scratch[lid + s];
+		}
+		barrier(CLK_LOCAL_MEM_FENCE);
+	}
+	if (lid == 0) {
+		out[get_group_id(0)] = scratch[0];
+	}
+}
+`
+
+func TestKernelArgs(t *testing.T) {
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	if !d.Supports("clGetKernelArgInfo") {
+		t.Skip("device does not implement OpenCL 1.2")
+	}
+	_, err = d.AddProgramWithOptions([]string{`__kernel void argKernel(__global float* a, __local int* tmp, const int n, __constant float* c) {
+	tmp[get_local_id(0)] = n;
+	a[get_global_id(0)] = c[0] * tmp[get_local_id(0)];
+}`}, &BuildOptions{KernelArgInfo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("argKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	args, err := k.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []KernelArg{
+		{Name: "a", TypeName: "float*", Address: constants.CL_KERNEL_ARG_ADDRESS_GLOBAL, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+		{Name: "tmp", TypeName: "int*", Address: constants.CL_KERNEL_ARG_ADDRESS_LOCAL, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+		{Name: "n", TypeName: "int", Address: constants.CL_KERNEL_ARG_ADDRESS_PRIVATE, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+		{Name: "c", TypeName: "float*", Address: constants.CL_KERNEL_ARG_ADDRESS_CONSTANT, Access: constants.CL_KERNEL_ARG_ACCESS_NONE},
+	}
+	if len(args) != len(expected) {
+		t.Fatal("expected 4 arguments, got", args)
+	}
+	for i, a := range args {
+		// drivers differ in type qualifiers of by-value and __constant arguments
+		a.TypeQualifier = 0
+		if a != expected[i] {
+			t.Fatalf("argument %d: expected %s, got %s", i, expected[i], a)
+		}
+	}
+	v, err := d.NewVector([]float32{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	_, err = k.Global(4).Local(1).CheckArgs().Run(nil, v, Local[int32](1), float32(3), v)
+	var mismatch ErrArgumentMismatch
+	if !errors.As(err, &mismatch) || mismatch.Index != 2 || mismatch.Arg.Name != "n" {
+		t.Fatal("expected mismatch of argument 2, got", err)
+	}
+	event, err := k.Global(4).Local(1).CheckArgs().Run(nil, v, Local[int32](1), int32(3), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = event.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalBuffer(t *testing.T) {
+	if Local[float32](256) != 1024 {
+		t.Fatal("expected 1024 bytes, got", Local[float32](256))
+	}
+	small, large := &Device{id: []pure.Device{1}}, &Device{id: []pure.Device{2}}
+	k := &Kernel{local: map[pure.Device]*localMemory{1: {device: 1024, kernel: 16}, 2: {device: 4096, kernel: 16}}}
+	if err := k.checkLocalMemory(small, []interface{}{Local[float32](200)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.checkLocalMemory(small, []interface{}{Local[float32](200), Local[float32](56)}); err == nil {
+		t.Fatal("local buffers do not fit into local memory")
+	}
+	if err := k.checkLocalMemory(large, []interface{}{Local[float32](200), Local[float32](56)}); err != nil {
+		t.Fatal("limits of other device were used:", err)
+	}
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	_, err = d.AddProgram(reductionKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err = d.Kernel("sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	data := make([]float32, 64)
+	for i := range data {
+		data[i] = 1
+	}
+	in, err := d.NewVector(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Release()
+	out, err := d.NewVector(make([]float32, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Release()
+	event, err := k.Global(64).Local(16).Run(nil, in, out, Local[float32](16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer event.Release()
+	sums, err := out.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if sums.Index(i).Float() != 16 {
+			t.Fatal("expected 16, got", sums.Index(i).Float())
+		}
+	}
+}
+
+func TestValueArguments(t *testing.T) {
+	type params struct {
+		Offset Float4
+		Scale  float32
+		N      int32
+		_      [8]byte
+	}
+	type misaligned struct {
+		Scale  float32
+		Offset Float4
+	}
+	type withInt struct {
+		N int
+	}
+	if unsafe.Sizeof(Float3{}) != 16 {
+		t.Fatal("float3 must have size of float4")
+	}
+	if err := ValidateLayout(params{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLayout([]Int4{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLayout(struct{ A, B float32 }{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{misaligned{}, withInt{}, "meh"} {
+		err := ValidateLayout(v)
+		if err == nil {
+			t.Fatalf("%T layout was accepted", v)
+		}
+		t.Log(err)
+	}
+	if _, _, err := valueArg(1 << 40); err == nil {
+		t.Fatal("int overflow was accepted")
+	}
+	if _, size, err := valueArg(true); err != nil || size != 4 {
+		t.Fatal("bool must be passed as int", err)
+	}
+	if _, _, err := valueArg("meh"); err != errUnsupportedValue {
+		t.Fatal("expected errUnsupportedValue, got", err)
+	}
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	_, err = d.AddProgram(`
+typedef struct { float4 offset; float scale; int n; } params;
+__kernel void affine(__global float4* a, params p, float4 bias, int flag) {
+	int i = get_global_id(0);
+	if (i < p.n && flag) {
+		a[i] = a[i] * p.scale + p.offset + bias;
+	}
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("affine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	v, err := d.NewVector([]Float4{{1, 1, 1, 1}, {2, 2, 2, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	event, err := k.Global(2).Local(1).Run(nil, v, params{Offset: Float4{1, 2, 3, 4}, Scale: 2, N: 2}, Float4{0, 0, 0, 1}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer event.Release()
+	data, err := v.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := data.Interface().([]Float4)[1]; result != (Float4{5, 6, 7, 9}) {
+		t.Fatal("unexpected", result)
+	}
+}
+
+func TestAutoLocal(t *testing.T) {
+	for _, c := range []struct {
+		global, maxItems []int
+		maxWorkGroup     int
+		multiple         int
+		expected         []int
+	}{
+		{[]int{1024}, []int{1024, 1024, 64}, 256, 32, []int{256}},
+		{[]int{1000}, []int{1024, 1024, 64}, 256, 32, []int{250}},
+		{[]int{96}, []int{1024, 1024, 64}, 256, 64, []int{96}},
+		{[]int{97}, []int{1024, 1024, 64}, 256, 32, []int{97}},
+		{[]int{101}, []int{64, 64, 64}, 64, 32, []int{1}},
+		{[]int{1920, 1080}, []int{1024, 1024, 64}, 256, 32, []int{32, 8}},
+		{[]int{512, 512}, []int{1024, 1024, 64}, 256, 32, []int{32, 8}},
+		{[]int{64, 64, 64}, []int{1024, 1024, 64}, 512, 8, []int{8, 8, 8}},
+		{[]int{16}, nil, 1, 32, []int{1}},
+	} {
+		local := autoLocalSizes(c.global, c.maxWorkGroup, c.multiple, c.maxItems)
+		if !reflect.DeepEqual(local, c.expected) {
+			t.Errorf("global %v: expected %v, got %v", c.global, c.expected, local)
+		}
+	}
+	device := &Device{id: []pure.Device{1}}
+	required := &Kernel{workGroup: map[pure.Device]*workGroupLimits{1: {info: &WorkGroupInfo{CompileWorkGroupSize: [3]int{64, 1, 1}}}}}
+	if local, err := required.autoLocal(device, []int{1024}); err != nil || !reflect.DeepEqual(local, []int{64}) {
+		t.Fatal("expected reqd_work_group_size 64, got", local, err)
+	}
+	if _, err := required.autoLocal(device, []int{1000}); err == nil {
+		t.Fatal("reqd_work_group_size not dividing global size was accepted")
+	}
+	for _, c := range []struct {
+		required [3]int
+		global   []int
+	}{
+		{[3]int{8, 8, 1}, []int{64}},
+		{[3]int{8, 1, 1}, []int{64, 64, 64, 64}},
+		{[3]int{8, 1, 1}, nil},
+	} {
+		if local, err := requiredLocal(c.required, c.global); err == nil {
+			t.Fatal("reqd_work_group_size", c.required, "accepted for", c.global, local)
+		}
+	}
+	if local, err := requiredLocal([3]int{8, 4, 1}, []int{64, 8}); err != nil || !reflect.DeepEqual(local, []int{8, 4}) {
+		t.Fatal("expected local sizes 8x4, got", local, err)
+	}
+	// the preferred multiple is missing on OpenCL 1.0
+	old := &Kernel{workGroup: map[pure.Device]*workGroupLimits{1: {info: &WorkGroupInfo{WorkGroupSize: 128}, maxItems: []int{128}}}}
+	if local, err := old.autoLocal(device, []int{1000}); err != nil || !reflect.DeepEqual(local, []int{125}) {
+		t.Fatal("expected local size 125 without preferred multiple, got", local, err)
+	}
+	// documented fallback when the work-group size of the kernel can not be queried
+	unknown := &Kernel{workGroup: map[pure.Device]*workGroupLimits{1: {}}}
+	if local, err := unknown.autoLocal(device, []int{1000, 10}); err != nil || !reflect.DeepEqual(local, []int{1, 1}) {
+		t.Fatal("expected local sizes 1 without limits, got", local, err)
+	}
+	err := Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	_, err = d.AddProgram(testKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("testKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	info, err := k.WorkGroupInfo(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", info)
+	v, err := d.NewVector(make([]float32, 1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	event, err := v.Map(k, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = event.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTuner(t *testing.T) {
+	candidates := powerOfTwoCandidates([]int{8, 12}, 16, []int{4, 64})
+	expected := [][]int{{1, 1}, {1, 2}, {1, 4}, {2, 1}, {2, 2}, {2, 4}, {4, 1}, {4, 2}, {4, 4}}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Fatalf("expected %v, got %v", expected, candidates)
+	}
+	path := filepath.Join(t.TempDir(), "tuner.json")
+	tuner, err := NewTuner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tuner.Results()) != 0 {
+		t.Fatal("new tuner has results")
+	}
+	err = os.WriteFile(path, []byte(`{"k|gpu 1.0|64": {"local": [32], "duration_ns": 1000}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuner, err = NewTuner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := tuner.Results()["k|gpu 1.0|64"]; !reflect.DeepEqual(r.Local, []int{32}) || r.Duration != time.Microsecond {
+		t.Fatal("unexpected", r)
+	}
+	err = Init(pure.Version2_0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := GetDefaultDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Release()
+	_, err = d.AddProgram(testKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := d.Kernel("testKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.ReleaseKernel()
+	v, err := d.NewVector(make([]float32, 1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Release()
+	kc, err := tuner.Tune(k.Global(1024), nil, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(kc.localWorkSizes, tuner.Results())
+	tuner, err = NewTuner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tuner.Results()) != 2 {
+		t.Fatal("tuned result was not saved")
+	}
+	_, err = d.AddProgram("__kernel __attribute__((reqd_work_group_size(4, 1, 1))) void fixedKernel(__global float* a) { a[get_global_id(0)] = 1.0f; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := d.Kernel("fixedKernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixed.ReleaseKernel()
+	if candidates, err = fixed.localCandidates(d, []int{8}); err != nil || !reflect.DeepEqual(candidates, [][]int{{4}}) {
+		t.Fatal("expected only reqd_work_group_size, got", candidates, err)
+	}
+	for _, global := range [][]int{{6}, {8, 1, 1, 1}} {
+		if _, err = tuner.Tune(fixed.Global(global...), nil, v); err == nil {
+			t.Fatal("tuned kernel with reqd_work_group_size for global sizes", global)
+		}
+	}
+}
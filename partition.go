@@ -0,0 +1,87 @@
+package highCL
+
+import (
+	"errors"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+)
+
+// DevicePartition describes how Device.Partition splits the device,
+// create it by PartitionEqually, PartitionByCounts or PartitionByAffinityDomain
+type DevicePartition struct {
+	properties []int
+}
+
+// PartitionEqually splits the device to as many sub-devices as possible, each with computeUnits compute units
+func PartitionEqually(computeUnits int) DevicePartition {
+	return DevicePartition{properties: []int{constants.CL_DEVICE_PARTITION_EQUALLY, computeUnits, 0}}
+}
+
+// PartitionByCounts creates one sub-device for every count with that many compute units
+func PartitionByCounts(counts ...int) DevicePartition {
+	properties := []int{constants.CL_DEVICE_PARTITION_BY_COUNTS}
+	properties = append(properties, counts...)
+	properties = append(properties, constants.CL_DEVICE_PARTITION_BY_COUNTS_LIST_END, 0)
+	return DevicePartition{properties: properties}
+}
+
+// PartitionByAffinityDomain splits the device along shared cache or NUMA node,
+// domain is one of CL_DEVICE_AFFINITY_DOMAIN_* constants
+func PartitionByAffinityDomain(domain pure.DeviceAffinityDomain) DevicePartition {
+	return DevicePartition{properties: []int{constants.CL_DEVICE_PARTITION_BY_AFFINITY_DOMAIN, int(domain), 0}}
+}
+
+// Partition splits the device to sub-devices, each of them has own context and queue,
+// it is useful to reserve compute units of CPU device for the go runtime.
+// Sub-devices are released by their Release or by Release of the parent device
+func (d *Device) Partition(p DevicePartition) ([]*Device, error) {
+	if err := d.require("clCreateSubDevices", "clReleaseDevice"); err != nil {
+		return nil, err
+	}
+	if len(p.properties) == 0 {
+		return nil, errors.New("cl: empty device partition")
+	}
+	var n uint32
+	err := pure.StatusToErr(createSubDevices(d.id[0], p.properties, 0, nil, &n))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]pure.Device, int(n))
+	err = pure.StatusToErr(createSubDevices(d.id[0], p.properties, n, ids, nil))
+	if err != nil {
+		return nil, err
+	}
+	subDevices := make([]*Device, 0, len(ids))
+	for i, id := range ids {
+		sub, err := newDevice([]pure.Device{id}, d.platform)
+		if err != nil {
+			for _, s := range subDevices {
+				err = pure.ErrJoin(err, s.Release())
+			}
+			for _, rest := range ids[i:] {
+				err = pure.ErrJoin(err, pure.StatusToErr(pure.ReleaseDevice(rest)))
+			}
+			return nil, err
+		}
+		sub.parent = d
+		sub.cache, _ = d.buildSettings()
+		d.children = append(d.children, sub)
+		subDevices = append(subDevices, sub)
+	}
+	return subDevices, nil
+}
+
+// Parent returns the device this sub-device was partitioned from, nil for root devices
+func (d *Device) Parent() *Device {
+	return d.parent
+}
+
+func (d *Device) removeChild(child *Device) {
+	for i, c := range d.children {
+		if c == child {
+			d.children = append(d.children[:i], d.children[i+1:]...)
+			break
+		}
+	}
+	child.parent = nil
+}
@@ -6,11 +6,25 @@ import (
 	"strings"
 )
 
+// Platform represents an OpenCL implementation (ICD vendor) with its devices
 type Platform struct {
 	p pure.Platform
 }
 
-func getPlatforms() ([]*Platform, error) {
+// PlatformInfo all string parameters of the platform
+type PlatformInfo struct {
+	Profile    string
+	Version    string
+	Name       string
+	Vendor     string
+	Extensions []pure.Extension
+}
+
+// GetPlatforms returns all available platforms
+func GetPlatforms() ([]*Platform, error) {
+	if err := require("clGetPlatformIDs"); err != nil {
+		return nil, err
+	}
 	numPlatforms := uint32(0)
 	st := pure.GetPlatformIDs(0, nil, &numPlatforms)
 	if st != constants.CL_SUCCESS {
@@ -29,6 +43,9 @@ func getPlatforms() ([]*Platform, error) {
 }
 
 func (p *Platform) getInfo(name pure.PlatformInfo) (string, error) {
+	if err := require("clGetPlatformInfo"); err != nil {
+		return "", err
+	}
 	size := pure.Size(0)
 	st := pure.GetPlatformInfo(p.p, name, pure.Size(0), nil, &size)
 	if st != constants.CL_SUCCESS {
@@ -61,3 +78,53 @@ func (p *Platform) GetExtensions() ([]pure.Extension, error) {
 	}
 	return strings.Split(extensions, " "), nil
 }
+
+// Info returns all platform parameters at once
+func (p *Platform) Info() (*PlatformInfo, error) {
+	var err, result error
+	info := &PlatformInfo{}
+	info.Profile, err = p.GetProfile()
+	result = pure.ErrJoin(result, err)
+	info.Version, err = p.GetVersion()
+	result = pure.ErrJoin(result, err)
+	info.Name, err = p.GetName()
+	result = pure.ErrJoin(result, err)
+	info.Vendor, err = p.GetVendor()
+	result = pure.ErrJoin(result, err)
+	info.Extensions, err = p.GetExtensions()
+	result = pure.ErrJoin(result, err)
+	return info, result
+}
+
+// Devices returns all devices of the platform with specified type,
+// empty slice when the platform has no such device
+func (p *Platform) Devices(deviceType pure.DeviceType) ([]*Device, error) {
+	if err := require("clGetDeviceIDs"); err != nil {
+		return nil, err
+	}
+	var n uint32
+	st := pure.GetDeviceIDs(p.p, deviceType, 0, nil, &n)
+	if st == constants.CL_DEVICE_NOT_FOUND || (st == constants.CL_SUCCESS && n == 0) {
+		return []*Device{}, nil
+	}
+	if st != constants.CL_SUCCESS {
+		return nil, pure.StatusToErr(st)
+	}
+	deviceIds := make([]pure.Device, int(n))
+	err := pure.StatusToErr(pure.GetDeviceIDs(p.p, deviceType, n, deviceIds, nil))
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]*Device, 0, len(deviceIds))
+	for _, id := range deviceIds {
+		device, err := newDevice([]pure.Device{id}, p)
+		if err != nil {
+			for _, d := range devices {
+				err = pure.ErrJoin(err, d.Release())
+			}
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
@@ -2,29 +2,164 @@
 package highCL
 
 import (
+	"errors"
 	constants "github.com/opencl-pure/constantsCL"
 	pure "github.com/opencl-pure/pureCL"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
 type Program struct {
-	program pure.Program
+	program  pure.Program
+	device   *Device     // device running kernels of the program, nil for programs of Context
+	context  *Context    // context of shared programs, kernels run on its first device which is not released
+	list     *[]*Program // programs of the device or of the context holding the program
+	listMu   *sync.Mutex // guards list
+	mu       sync.Mutex  // guards released
+	released bool
 }
 
-// GetBinaries Return the program binaries associated with program.
-func (p *Program) GetBinaries() ([][]byte, error) {
-	var devices pure.Device
-	err := pure.StatusToErr(pure.GetProgramInfo(p.program, pure.ProgramBuildInfo(constants.CL_PROGRAM_NUM_DEVICES), pure.Size(4), unsafe.Pointer(&devices), nil))
+// ErrProgramReleased the program, or the program of the kernel, was released
+var ErrProgramReleased = errors.New("cl: program is released")
+
+// Release releases the program and removes it from its device (context),
+// kernels created from the program can not run anymore, release them by Kernel.ReleaseKernel
+func (p *Program) Release() error {
+	if err := require("clReleaseProgram"); err != nil {
+		return err
+	}
+	if p.list == nil {
+		return p.release()
+	}
+	p.listMu.Lock()
+	defer p.listMu.Unlock()
+	if err := p.release(); err != nil {
+		return err
+	}
+	for i, other := range *p.list {
+		if other == p {
+			*p.list = append((*p.list)[:i], (*p.list)[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Released reports whether the program was released by Release, Device.Release or Context.Release
+func (p *Program) Released() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.released
+}
+
+// release releases the program without removing it from its list,
+// the program stays usable when clReleaseProgram fails
+func (p *Program) release() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.released {
+		return ErrProgramReleased
+	}
+	if err := pure.StatusToErr(pure.ReleaseProgram(p.program)); err != nil {
+		return err
+	}
+	p.released = true
+	return nil
+}
+
+// check returns ErrProgramReleased for released program
+func (p *Program) check() error {
+	if p.Released() {
+		return ErrProgramReleased
+	}
+	return nil
+}
+
+// Kernel returns kernel of the program
+func (p *Program) Kernel(name string) (*Kernel, error) {
+	if err := require("clCreateKernel"); err != nil {
+		return nil, err
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	device := p.device
+	if p.context != nil {
+		if device = p.context.device(); device == nil {
+			return nil, ErrDeviceReleased
+		}
+	}
+	if device == nil {
+		return nil, errors.New("cl: program has no kernels")
+	}
+	var ret pure.Status
+	k := pure.CreateKernel(p.program, name, &ret)
+	if ret == constants.CL_INVALID_KERNEL_NAME {
+		return nil, ErrUnknownKernel{Name: name}
+	}
+	if err := pure.StatusToErr(ret); err != nil {
+		return nil, err
+	}
+	return newKernel(device, p, k, name), nil
+}
+
+// KernelNames returns names of all kernels of the program (OpenCL 1.2)
+func (p *Program) KernelNames() ([]string, error) {
+	if err := require("clGetProgramInfo"); err != nil {
+		return nil, err
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	var n pure.Size
+	err := pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_KERNEL_NAMES, 0, nil, &n))
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return []string{}, nil
+	}
+	names := make([]byte, n)
+	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_KERNEL_NAMES, n, unsafe.Pointer(&names[0]), nil))
 	if err != nil {
 		return nil, err
 	}
-	deviceIDs := make([]pure.Device, devices)
-	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_DEVICES, pure.Size(len(deviceIDs)*4), unsafe.Pointer(&deviceIDs[0]), nil))
+	return splitKernelNames(string(names)), nil
+}
+
+// splitKernelNames splits semicolon separated kernel names
+func splitKernelNames(names string) []string {
+	res := []string{}
+	for _, name := range strings.Split(strings.TrimRight(names, "\x00"), ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// GetBinaries Return the program binaries associated with program,
+// one binary per device of the program in CL_PROGRAM_DEVICES order
+func (p *Program) GetBinaries() ([][]byte, error) {
+	if err := require("clGetProgramInfo"); err != nil {
+		return nil, err
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	var devices uint32
+	err := pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_NUM_DEVICES, pure.Size(unsafe.Sizeof(devices)), unsafe.Pointer(&devices), nil))
 	if err != nil {
 		return nil, err
 	}
+	if devices == 0 {
+		return [][]byte{}, nil
+	}
 	binarySizes := make([]pure.Size, devices)
-	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_BINARY_SIZES, pure.Size(len(deviceIDs)*4), unsafe.Pointer(&binarySizes[0]), nil))
+	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_BINARY_SIZES, pure.Size(uintptr(len(binarySizes))*unsafe.Sizeof(binarySizes[0])), unsafe.Pointer(&binarySizes[0]), nil))
 	if err != nil {
 		return nil, err
 	}
@@ -32,16 +167,130 @@ func (p *Program) GetBinaries() ([][]byte, error) {
 	binaries := make([][]byte, devices)
 	cBinaries := make([]unsafe.Pointer, devices)
 	for i, size := range binarySizes {
-		cBinaries[i] = unsafe.Pointer(&make([]byte, size)[0])
+		binaries[i] = make([]byte, size)
+		if size > 0 {
+			cBinaries[i] = unsafe.Pointer(&binaries[i][0])
+		}
 	}
-	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_BINARIES, pure.Size(len(cBinaries)*4), unsafe.Pointer(&cBinaries[0]), nil))
+	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_BINARIES, pure.Size(uintptr(len(cBinaries))*unsafe.Sizeof(cBinaries[0])), unsafe.Pointer(&cBinaries[0]), nil))
+	runtime.KeepAlive(binaries)
 	if err != nil {
 		return nil, err
 	}
+	return binaries, nil
+}
 
-	for i, size := range binarySizes {
-		binaries[i] = (*(*[1 << 20]byte)(cBinaries[i]))[:size]
+// BuildStatus status of the last build (compile, link) of the program for one device
+type BuildStatus int32
+
+func (s BuildStatus) String() string {
+	switch s {
+	case constants.CL_BUILD_SUCCESS:
+		return "success"
+	case constants.CL_BUILD_NONE:
+		return "none"
+	case constants.CL_BUILD_ERROR:
+		return "error"
+	case constants.CL_BUILD_IN_PROGRESS:
+		return "in progress"
 	}
+	return "unknown build status " + strconv.Itoa(int(s))
+}
 
-	return binaries, nil
+// DeviceBuildStatus build status of one device of the program
+type DeviceBuildStatus struct {
+	Device string // name of the device
+	Status BuildStatus
+}
+
+// BuildStatus returns build status for every device of the program
+func (p *Program) BuildStatus() ([]DeviceBuildStatus, error) {
+	if err := require("clGetProgramBuildInfo"); err != nil {
+		return nil, err
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	devices, err := p.devices()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]DeviceBuildStatus, len(devices))
+	for i, id := range devices {
+		var status BuildStatus
+		err = pure.StatusToErr(pure.GetProgramBuildInfo(p.program, id, constants.CL_PROGRAM_BUILD_STATUS, pure.Size(unsafe.Sizeof(status)), unsafe.Pointer(&status), nil))
+		if err != nil {
+			return nil, err
+		}
+		if res[i].Device, err = deviceName(id); err != nil {
+			return nil, err
+		}
+		res[i].Status = status
+	}
+	return res, nil
+}
+
+// BuildLog returns build log with parsed diagnostics for every device of the program,
+// logs of successful builds carry warnings of the compiler
+func (p *Program) BuildLog() ([]DeviceBuildLog, error) {
+	if err := require("clGetProgramBuildInfo"); err != nil {
+		return nil, err
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	devices, err := p.devices()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]DeviceBuildLog, len(devices))
+	for i, id := range devices {
+		if res[i].Device, err = deviceName(id); err != nil {
+			return nil, err
+		}
+		res[i].Log = buildLog(p.program, id)
+		res[i].Diagnostics = ParseDiagnostics(res[i].Log)
+	}
+	return res, nil
+}
+
+// devices devices associated with the program
+func (p *Program) devices() ([]pure.Device, error) {
+	if err := require("clGetProgramInfo"); err != nil {
+		return nil, err
+	}
+	var n uint32
+	err := pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_NUM_DEVICES, pure.Size(unsafe.Sizeof(n)), unsafe.Pointer(&n), nil))
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	devices := make([]pure.Device, n)
+	err = pure.StatusToErr(pure.GetProgramInfo(p.program, constants.CL_PROGRAM_DEVICES, pure.Size(uintptr(n)*unsafe.Sizeof(devices[0])), unsafe.Pointer(&devices[0]), nil))
+	if err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// BuildLogHook receives non-empty build logs of successful builds, e.g. warnings about register spills
+type BuildLogHook func(p *Program, logs []DeviceBuildLog)
+
+// reportBuildLog passes non-empty logs of successfully built program to the hook
+func reportBuildLog(hook BuildLogHook, p *Program) {
+	if hook == nil {
+		return
+	}
+	logs, err := p.BuildLog()
+	if err != nil {
+		return
+	}
+	nonEmpty := logs[:0]
+	for _, l := range logs {
+		if strings.TrimSpace(l.Log) != "" {
+			nonEmpty = append(nonEmpty, l)
+		}
+	}
+	if len(nonEmpty) > 0 {
+		hook(p, nonEmpty)
+	}
 }
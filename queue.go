@@ -0,0 +1,76 @@
+package highCL
+
+import (
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+)
+
+// QueueOptions properties of the command queue
+type QueueOptions struct {
+	// OutOfOrder commands may execute in any order, use events to order them
+	OutOfOrder bool
+	// Profiling events of the queue carry timestamps, see Event.Profile
+	Profiling bool
+}
+
+func (o QueueOptions) properties() uint64 {
+	var properties uint64
+	if o.OutOfOrder {
+		properties |= constants.CL_QUEUE_OUT_OF_ORDER_EXEC_MODE_ENABLE
+	}
+	if o.Profiling {
+		properties |= constants.CL_QUEUE_PROFILING_ENABLE
+	}
+	return properties
+}
+
+// Queue is an additional command queue of the device,
+// kernels and transfers can target it to overlap with work of other queues
+type Queue struct {
+	queue   pure.CommandQueue
+	device  *Device
+	options QueueOptions
+}
+
+// NewQueue creates new command queue on the device, the caller is responsible to release it
+func (d *Device) NewQueue(opts QueueOptions) (*Queue, error) {
+	queue, err := newQueueWithProperties(d, opts.properties())
+	if err != nil {
+		return nil, err
+	}
+	return &Queue{queue: queue, device: d, options: opts}, nil
+}
+
+// Device the device of the queue
+func (q *Queue) Device() *Device {
+	return q.device
+}
+
+// Options the options the queue was created with
+func (q *Queue) Options() QueueOptions {
+	return q.options
+}
+
+// Flush issues all queued commands to the device
+func (q *Queue) Flush() error {
+	if err := require("clFlush"); err != nil {
+		return err
+	}
+	return pure.StatusToErr(pure.FlushCommandQueue(q.queue))
+}
+
+// Finish blocks until all queued commands are completed
+func (q *Queue) Finish() error {
+	if err := require("clFinish"); err != nil {
+		return err
+	}
+	return pure.StatusToErr(pure.FinishCommandQueue(q.queue))
+}
+
+// Release releases the queue
+func (q *Queue) Release() error {
+	if err := require("clReleaseCommandQueue"); err != nil {
+		return err
+	}
+	return pure.StatusToErr(pure.ReleaseCommandQueue(q.queue))
+}
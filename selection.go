@@ -0,0 +1,189 @@
+package highCL
+
+import (
+	"errors"
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DeviceEnv environment variable which overrides the device chosen by SelectDevice, it can hold
+//   - index of the device in GetDevices(CL_DEVICE_TYPE_ALL) order, e.g. "1"
+//   - device type, one of "gpu", "cpu", "accelerator", "default", the best ranked device of this type is chosen
+//   - any other text is case-insensitive substring of device name, device vendor or platform name
+const DeviceEnv = "HIGHCL_DEVICE"
+
+// DeviceCriteria describes which devices SelectDevice accepts, zero values do not filter
+type DeviceCriteria struct {
+	// Type device type bitmask (CL_DEVICE_TYPE_GPU, ...), zero means any type
+	Type pure.DeviceType
+	// Vendor case-insensitive substring of the device vendor
+	Vendor string
+	// Name case-insensitive substring of the device name
+	Name string
+	// MinGlobalMemSize minimal global memory size in bytes
+	MinGlobalMemSize uint64
+	// Extensions all of them must be supported by the device
+	Extensions []pure.Extension
+	// MinVersion minimal OpenCL version of the device, e.g. pure.Version1_2
+	MinVersion pure.Version
+}
+
+// ErrNoDevice no device satisfies the criteria or the DeviceEnv value
+var ErrNoDevice = errors.New("cl: no device satisfies the criteria")
+
+// SelectDevice returns the best ranked device of all platforms which satisfies criteria,
+// devices are ranked by type (GPU, accelerator, CPU), compute units and global memory.
+// When DeviceEnv is set, its value replaces the criteria.
+// Not selected devices are released.
+func SelectDevice(criteria DeviceCriteria) (*Device, error) {
+	devices, err := GetDevices(constants.CL_DEVICE_TYPE_ALL)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]deviceCandidate, len(devices))
+	for i, d := range devices {
+		candidates[i] = deviceCandidate{device: d, index: i}
+		candidates[i].info, _ = d.Info() // partially filled info is good enough for ranking
+		candidates[i].platformName, _ = d.PlatformName()
+	}
+	var i int
+	if env := strings.TrimSpace(os.Getenv(DeviceEnv)); env != "" {
+		i, err = selectByEnv(candidates, env)
+	} else {
+		i, err = selectByCriteria(candidates, criteria)
+	}
+	for j, d := range devices {
+		if j == i && err == nil {
+			continue
+		}
+		if err2 := d.Release(); err2 != nil {
+			log.Println(err2)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return devices[i], nil
+}
+
+type deviceCandidate struct {
+	device       *Device
+	index        int
+	info         *DeviceInfo
+	platformName string
+}
+
+func selectByCriteria(candidates []deviceCandidate, criteria DeviceCriteria) (int, error) {
+	var accepted []deviceCandidate
+	for _, c := range candidates {
+		if criteria.matches(c.info) {
+			accepted = append(accepted, c)
+		}
+	}
+	if len(accepted) == 0 {
+		return -1, ErrNoDevice
+	}
+	rankDevices(accepted)
+	return accepted[0].index, nil
+}
+
+func selectByEnv(candidates []deviceCandidate, env string) (int, error) {
+	if i, err := strconv.Atoi(env); err == nil {
+		if i < 0 || i >= len(candidates) {
+			return -1, fmt.Errorf("cl: %s=%s is out of range, there are %d devices", DeviceEnv, env, len(candidates))
+		}
+		return i, nil
+	}
+	lower := strings.ToLower(env)
+	var deviceType pure.DeviceType
+	switch lower {
+	case "gpu":
+		deviceType = constants.CL_DEVICE_TYPE_GPU
+	case "cpu":
+		deviceType = constants.CL_DEVICE_TYPE_CPU
+	case "accelerator":
+		deviceType = constants.CL_DEVICE_TYPE_ACCELERATOR
+	case "default":
+		deviceType = constants.CL_DEVICE_TYPE_DEFAULT
+	}
+	var accepted []deviceCandidate
+	for _, c := range candidates {
+		if deviceType != 0 {
+			if c.info.Type&deviceType != 0 {
+				accepted = append(accepted, c)
+			}
+			continue
+		}
+		if strings.Contains(strings.ToLower(c.info.Name), lower) ||
+			strings.Contains(strings.ToLower(c.info.Vendor), lower) ||
+			strings.Contains(strings.ToLower(c.platformName), lower) {
+			accepted = append(accepted, c)
+		}
+	}
+	if len(accepted) == 0 {
+		return -1, fmt.Errorf("%w: %s=%s", ErrNoDevice, DeviceEnv, env)
+	}
+	rankDevices(accepted)
+	return accepted[0].index, nil
+}
+
+func (c DeviceCriteria) matches(info *DeviceInfo) bool {
+	if c.Type != 0 && info.Type&c.Type == 0 {
+		return false
+	}
+	if c.Vendor != "" && !strings.Contains(strings.ToLower(info.Vendor), strings.ToLower(c.Vendor)) {
+		return false
+	}
+	if c.Name != "" && !strings.Contains(strings.ToLower(info.Name), strings.ToLower(c.Name)) {
+		return false
+	}
+	if info.GlobalMemSize < c.MinGlobalMemSize {
+		return false
+	}
+	if !NewExtensionSet(info.Extensions).HasAll(c.Extensions...) {
+		return false
+	}
+	if c.MinVersion != "" {
+		minVersion, err := ParseVersion(string(c.MinVersion))
+		if err != nil {
+			return false
+		}
+		version, err := ParseVersion(info.Version)
+		if err != nil || version.Compare(minVersion) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// rankDevices sorts candidates from the best one
+func rankDevices(candidates []deviceCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].info, candidates[j].info
+		if typeRank(a.Type) != typeRank(b.Type) {
+			return typeRank(a.Type) > typeRank(b.Type)
+		}
+		if a.MaxComputeUnits != b.MaxComputeUnits {
+			return a.MaxComputeUnits > b.MaxComputeUnits
+		}
+		return a.GlobalMemSize > b.GlobalMemSize
+	})
+}
+
+func typeRank(t pure.DeviceType) int {
+	switch {
+	case t&constants.CL_DEVICE_TYPE_GPU != 0:
+		return 3
+	case t&constants.CL_DEVICE_TYPE_ACCELERATOR != 0:
+		return 2
+	case t&constants.CL_DEVICE_TYPE_CPU != 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+package highCL
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AddProgramFS compiles program from file entry of fsys (embed.FS, os.DirFS, ...) with build options,
+// #include directives are resolved against fsys, relative to the including file and then to includeDirs of fsys,
+// #line markers keep file names and lines of build errors pointing to the original files
+func (d *Device) AddProgramFS(fsys fs.FS, entry string, opts *BuildOptions, includeDirs ...string) (*Program, error) {
+	source, err := ResolveIncludes(fsys, entry, includeDirs)
+	if err != nil {
+		return nil, err
+	}
+	return d.AddProgramWithOptions([]string{source}, opts)
+}
+
+var (
+	includeRegexp    = regexp.MustCompile(`^\s*#\s*include\s*(?:"([^"]+)"|<([^>]+)>)`)
+	pragmaOnceRegexp = regexp.MustCompile(`^\s*#\s*pragma\s+once\b`)
+)
+
+// ResolveIncludes returns source of file entry of fsys with all #include directives replaced by the included files,
+// headers with #pragma once are included once, include cycles and missing "quoted" headers are errors,
+// missing <angled> headers are left to the compiler
+func ResolveIncludes(fsys fs.FS, entry string, dirs []string) (string, error) {
+	r := &includeResolver{fsys: fsys, dirs: dirs, once: map[string]bool{}}
+	if err := r.include(path.Clean(entry)); err != nil {
+		return "", err
+	}
+	return r.sb.String(), nil
+}
+
+type includeResolver struct {
+	fsys  fs.FS
+	dirs  []string
+	once  map[string]bool // files with #pragma once already included
+	stack []string        // files being included, for cycle detection
+	sb    strings.Builder
+}
+
+func (r *includeResolver) include(name string) error {
+	for _, f := range r.stack {
+		if f == name {
+			return fmt.Errorf("cl: include cycle %s -> %s", strings.Join(r.stack, " -> "), name)
+		}
+	}
+	source, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return err
+	}
+	r.stack = append(r.stack, name)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	r.sb.WriteString("#line 1 " + lineFileName(name) + "\n")
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		if pragmaOnceRegexp.MatchString(line) {
+			r.once[name] = true
+			r.sb.WriteString("\n")
+			continue
+		}
+		m := includeRegexp.FindStringSubmatch(line)
+		if m == nil {
+			r.sb.WriteString(line + "\n")
+			continue
+		}
+		header, quoted := m[1], m[1] != ""
+		if !quoted {
+			header = m[2]
+		}
+		resolved, ok := r.resolve(path.Dir(name), header, quoted)
+		if !ok {
+			if quoted {
+				return fmt.Errorf("cl: %s:%d: include file %q not found", name, i+1, header)
+			}
+			r.sb.WriteString(line + "\n")
+			continue
+		}
+		if r.once[resolved] {
+			r.sb.WriteString("\n")
+			continue
+		}
+		if err = r.include(resolved); err != nil {
+			return err
+		}
+		r.sb.WriteString("#line " + strconv.Itoa(i+2) + " " + lineFileName(name) + "\n")
+	}
+	return nil
+}
+
+// lineFileName quoted file name of #line marker, only \ and " are escaped as C compilers do not know Go escapes
+func lineFileName(name string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
+
+// resolve finds header in fsys, "quoted" headers are searched relative to dir first
+func (r *includeResolver) resolve(dir, header string, quoted bool) (string, bool) {
+	var candidates []string
+	if quoted {
+		candidates = append(candidates, path.Join(dir, header))
+	}
+	for _, d := range r.dirs {
+		candidates = append(candidates, path.Join(d, header))
+	}
+	candidates = append(candidates, path.Clean(header))
+	for _, c := range candidates {
+		if !fs.ValidPath(c) {
+			continue
+		}
+		if info, err := fs.Stat(r.fsys, c); err == nil && !info.IsDir() {
+			return c, true
+		}
+	}
+	return "", false
+}
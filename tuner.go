@@ -0,0 +1,234 @@
+package highCL
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TuneResult the fastest local work sizes of one kernel launch
+type TuneResult struct {
+	Local    []int         `json:"local"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Tuner chooses the fastest local work sizes of kernel launches by timing them with profiling events,
+// results are keyed by kernel name, device and global work sizes and saved to JSON file,
+// NewTuner loads them so later runs do not tune again
+type Tuner struct {
+	// Repeats runs of every candidate, the shortest run is taken, 3 when zero
+	Repeats int
+	path    string
+	results map[string]TuneResult
+	mu      sync.Mutex
+}
+
+// NewTuner creates tuner persisting results into JSON file path, existing results are loaded
+func NewTuner(path string) (*Tuner, error) {
+	t := &Tuner{path: path, results: map[string]TuneResult{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &t.results); err != nil {
+		return nil, fmt.Errorf("cl: tuner file %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Results returns copy of all results
+func (t *Tuner) Results() map[string]TuneResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make(map[string]TuneResult, len(t.results))
+	for k, v := range t.results {
+		res[k] = v
+	}
+	return res
+}
+
+// Tune returns kc with the fastest local work sizes, known results are reused without running the kernel.
+// Otherwise the kernel runs with args for every candidate (nil candidates means search space of power of two sizes)
+// on a profiling queue of its device, so the kernel must tolerate repeated runs with the same arguments
+func (t *Tuner) Tune(kc KernelCall, candidates [][]int, args ...interface{}) (KernelCall, error) {
+	if err := kc.kernel.program.check(); err != nil {
+		return kc, err
+	}
+	device := kc.kernel.d
+	if kc.queue != nil {
+		device = kc.queue.device
+	}
+	key, err := tuneKey(kc, device)
+	if err != nil {
+		return kc, err
+	}
+	kc.autoLocal = false
+	t.mu.Lock()
+	result, ok := t.results[key]
+	t.mu.Unlock()
+	if ok {
+		return kc.Local(result.Local...), nil
+	}
+	if candidates == nil {
+		if candidates, err = kc.kernel.localCandidates(device, kc.globalWorkSizes); err != nil {
+			return kc, err
+		}
+	}
+	result, err = t.measure(kc, device, candidates, args)
+	if err != nil {
+		return kc, err
+	}
+	t.mu.Lock()
+	t.results[key] = result
+	err = t.save()
+	t.mu.Unlock()
+	return kc.Local(result.Local...), err
+}
+
+// measure times all candidates and returns the fastest one
+func (t *Tuner) measure(kc KernelCall, device *Device, candidates [][]int, args []interface{}) (TuneResult, error) {
+	q, err := device.NewQueue(QueueOptions{Profiling: true})
+	if err != nil {
+		return TuneResult{}, err
+	}
+	defer q.Release()
+	repeats := t.Repeats
+	if repeats <= 0 {
+		repeats = 3
+	}
+	best := TuneResult{Duration: -1}
+	var result error
+	for _, local := range candidates {
+		duration, err := measureLocal(kc.Local(local...).Queue(q), repeats, args)
+		if err != nil {
+			result = pure.ErrJoin(result, fmt.Errorf("cl: local %v: %w", local, err))
+			continue
+		}
+		if best.Duration < 0 || duration < best.Duration {
+			best = TuneResult{Local: local, Duration: duration}
+		}
+	}
+	if best.Duration < 0 {
+		return best, pure.ErrJoin(errors.New("cl: no candidate of local work sizes has run"), result)
+	}
+	return best, nil
+}
+
+// measureLocal the shortest of repeats runs, the first run warms up and it is not measured
+func measureLocal(kc KernelCall, repeats int, args []interface{}) (time.Duration, error) {
+	shortest := time.Duration(-1)
+	for i := 0; i <= repeats; i++ {
+		event, err := kc.Run(nil, args...)
+		if err != nil {
+			return 0, err
+		}
+		err = event.Wait()
+		var profile *EventProfile
+		if err == nil {
+			profile, err = event.Profile()
+		}
+		if err = pure.ErrJoin(err, event.Release()); err != nil {
+			return 0, err
+		}
+		if i > 0 && (shortest < 0 || profile.Duration() < shortest) {
+			shortest = profile.Duration()
+		}
+	}
+	return shortest, nil
+}
+
+// save writes results to the file, the caller holds the lock
+func (t *Tuner) save() error {
+	data, err := json.MarshalIndent(t.results, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	err = pure.ErrJoin(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), t.path)
+	}
+	if err != nil {
+		return pure.ErrJoin(err, os.Remove(tmp.Name()))
+	}
+	return nil
+}
+
+// tuneKey kernel name, device name, driver version and global work sizes
+func tuneKey(kc KernelCall, device *Device) (string, error) {
+	name, err := kc.kernel.Name()
+	if err != nil {
+		return "", err
+	}
+	deviceName, err := device.Name()
+	if err != nil {
+		return "", err
+	}
+	driver, err := device.DriverVersion()
+	if err != nil {
+		return "", err
+	}
+	global := make([]string, len(kc.globalWorkSizes))
+	for i, g := range kc.globalWorkSizes {
+		global[i] = strconv.Itoa(g)
+	}
+	return name + "|" + deviceName + " " + driver + "|" + strings.Join(global, "x"), nil
+}
+
+// localCandidates search space of local work sizes for global work sizes on the device
+func (k *Kernel) localCandidates(device *Device, global []int) ([][]int, error) {
+	info, err := k.WorkGroupInfo(device)
+	if err != nil {
+		return nil, err
+	}
+	if c := info.CompileWorkGroupSize; c[0] != 0 {
+		local, err := requiredLocal(c, global)
+		if err != nil {
+			return nil, err
+		}
+		return [][]int{local}, nil
+	}
+	maxItems, err := device.GetInfoSizeArray(constants.CL_DEVICE_MAX_WORK_ITEM_SIZES)
+	if err != nil {
+		return nil, err
+	}
+	return powerOfTwoCandidates(global, info.WorkGroupSize, maxItems), nil
+}
+
+// powerOfTwoCandidates all combinations of power of two local sizes which divide global sizes,
+// fit into maxItems and whose product is at most maxWorkGroup
+func powerOfTwoCandidates(global []int, maxWorkGroup int, maxItems []int) [][]int {
+	res := [][]int{{}}
+	for i, g := range global {
+		var next [][]int
+		for _, prefix := range res {
+			product := 1
+			for _, l := range prefix {
+				product *= l
+			}
+			for l := 1; product*l <= maxWorkGroup && l <= g; l *= 2 {
+				if g%l != 0 || (i < len(maxItems) && l > maxItems[i]) {
+					continue
+				}
+				next = append(next, append(prefix[:len(prefix):len(prefix)], l))
+			}
+		}
+		res = next
+	}
+	return res
+}
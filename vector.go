@@ -57,6 +57,16 @@ func (d *Device) NewVector(data interface{}) (*Vector, error) {
 // it is usefully for recall kernel with others data without locate new vector
 // it's a non-blocking call, channel will return an error or nil if the data transfer is complete
 func (v *Vector) Reset(data interface{}) <-chan error {
+	return v.reset(v.buf.device.queue, data)
+}
+
+// ResetOn is Reset using the queue
+// it's a non-blocking call, channel will return an error or nil if the data transfer is complete
+func (v *Vector) ResetOn(q *Queue, data interface{}) <-chan error {
+	return v.reset(q.queue, data)
+}
+
+func (v *Vector) reset(queue pure.CommandQueue, data interface{}) <-chan error {
 	dataType := reflect.TypeOf(data)
 	if dataType != v.typ {
 		ch := make(chan error, 1)
@@ -70,24 +80,23 @@ func (v *Vector) Reset(data interface{}) <-chan error {
 		ch <- errors.New("vector length not equal to data length")
 		return ch
 	}
-	return v.buf.copy(l*v.iSize, unsafe.Pointer(slice.Pointer()))
+	return v.buf.copyOn(queue, l*v.iSize, unsafe.Pointer(slice.Pointer()))
 }
 
 // Data gets data *reflect.Value in from device, it's a blocking call
 // use v, err := Data(); elen := any(retrievedData.Index(i).Float()) ...
 func (v *Vector) Data() (*reflect.Value, error) {
+	return v.data(v.buf.device.queue)
+}
+
+// DataOn is Data using the queue, it's a blocking call
+func (v *Vector) DataOn(q *Queue) (*reflect.Value, error) {
+	return v.data(q.queue)
+}
+
+func (v *Vector) data(queue pure.CommandQueue) (*reflect.Value, error) {
 	data := reflect.MakeSlice(v.typ, v.len, v.len)
-	err := pure.StatusToErr(pure.EnqueueReadBuffer(
-		v.buf.device.queue,
-		v.buf.memobj,
-		true,
-		0,
-		v.buf.size,
-		unsafe.Pointer(data.Pointer()),
-		0,
-		nil,
-		nil,
-	))
+	err := v.buf.read(queue, unsafe.Pointer(data.Pointer()))
 	if err != nil {
 		return nil, err
 	}
@@ -115,9 +124,9 @@ func (v *Vector) DataArray() (*reflect.Value, error) {
 	return &data, nil
 }
 
-// Map applies an map kernel on all elements of the vector
+// Map applies an map kernel on all elements of the vector, local work sizes are chosen by KernelCall.AutoLocal
 // It's a non-blocking call, so it can return an event object that you can wait on.
 // The caller is responsible to release the returned event when it's not used anymore.
 func (v *Vector) Map(k *Kernel, waitEvents []*Event) (*Event, error) {
-	return k.Global(v.Length()).Local(1).Run(waitEvents, v)
+	return k.Global(v.Length()).AutoLocal().Run(waitEvents, v)
 }
@@ -0,0 +1,184 @@
+package highCL
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// OpenCL vector types, they can be passed to kernels by value, stored in Vector and used as fields of structs.
+// 3-component types have the size and alignment of 4-component types, the fourth component is padding.
+// Their alignment in OpenCL is their size, see ValidateLayout for structs containing them
+type (
+	Char2  [2]int8  // char2
+	Char3  [4]int8  // char3
+	Char4  [4]int8  // char4
+	Char8  [8]int8  // char8
+	Char16 [16]int8 // char16
+
+	UChar2  [2]uint8  // uchar2
+	UChar3  [4]uint8  // uchar3
+	UChar4  [4]uint8  // uchar4
+	UChar8  [8]uint8  // uchar8
+	UChar16 [16]uint8 // uchar16
+
+	Short2  [2]int16  // short2
+	Short3  [4]int16  // short3
+	Short4  [4]int16  // short4
+	Short8  [8]int16  // short8
+	Short16 [16]int16 // short16
+
+	UShort2  [2]uint16  // ushort2
+	UShort3  [4]uint16  // ushort3
+	UShort4  [4]uint16  // ushort4
+	UShort8  [8]uint16  // ushort8
+	UShort16 [16]uint16 // ushort16
+
+	Int2  [2]int32  // int2
+	Int3  [4]int32  // int3
+	Int4  [4]int32  // int4
+	Int8  [8]int32  // int8
+	Int16 [16]int32 // int16
+
+	UInt2  [2]uint32  // uint2
+	UInt3  [4]uint32  // uint3
+	UInt4  [4]uint32  // uint4
+	UInt8  [8]uint32  // uint8
+	UInt16 [16]uint32 // uint16
+
+	Long2  [2]int64  // long2
+	Long3  [4]int64  // long3
+	Long4  [4]int64  // long4
+	Long8  [8]int64  // long8
+	Long16 [16]int64 // long16
+
+	ULong2  [2]uint64  // ulong2
+	ULong3  [4]uint64  // ulong3
+	ULong4  [4]uint64  // ulong4
+	ULong8  [8]uint64  // ulong8
+	ULong16 [16]uint64 // ulong16
+
+	Float2  [2]float32  // float2
+	Float3  [4]float32  // float3
+	Float4  [4]float32  // float4
+	Float8  [8]float32  // float8
+	Float16 [16]float32 // float16
+
+	Double2  [2]float64  // double2
+	Double3  [4]float64  // double3
+	Double4  [4]float64  // double4
+	Double8  [8]float64  // double8
+	Double16 [16]float64 // double16
+)
+
+// vectorTypes all OpenCL vector types
+var vectorTypes = map[reflect.Type]bool{
+	reflect.TypeOf(Char2{}): true, reflect.TypeOf(Char3{}): true, reflect.TypeOf(Char4{}): true, reflect.TypeOf(Char8{}): true, reflect.TypeOf(Char16{}): true,
+	reflect.TypeOf(UChar2{}): true, reflect.TypeOf(UChar3{}): true, reflect.TypeOf(UChar4{}): true, reflect.TypeOf(UChar8{}): true, reflect.TypeOf(UChar16{}): true,
+	reflect.TypeOf(Short2{}): true, reflect.TypeOf(Short3{}): true, reflect.TypeOf(Short4{}): true, reflect.TypeOf(Short8{}): true, reflect.TypeOf(Short16{}): true,
+	reflect.TypeOf(UShort2{}): true, reflect.TypeOf(UShort3{}): true, reflect.TypeOf(UShort4{}): true, reflect.TypeOf(UShort8{}): true, reflect.TypeOf(UShort16{}): true,
+	reflect.TypeOf(Int2{}): true, reflect.TypeOf(Int3{}): true, reflect.TypeOf(Int4{}): true, reflect.TypeOf(Int8{}): true, reflect.TypeOf(Int16{}): true,
+	reflect.TypeOf(UInt2{}): true, reflect.TypeOf(UInt3{}): true, reflect.TypeOf(UInt4{}): true, reflect.TypeOf(UInt8{}): true, reflect.TypeOf(UInt16{}): true,
+	reflect.TypeOf(Long2{}): true, reflect.TypeOf(Long3{}): true, reflect.TypeOf(Long4{}): true, reflect.TypeOf(Long8{}): true, reflect.TypeOf(Long16{}): true,
+	reflect.TypeOf(ULong2{}): true, reflect.TypeOf(ULong3{}): true, reflect.TypeOf(ULong4{}): true, reflect.TypeOf(ULong8{}): true, reflect.TypeOf(ULong16{}): true,
+	reflect.TypeOf(Float2{}): true, reflect.TypeOf(Float3{}): true, reflect.TypeOf(Float4{}): true, reflect.TypeOf(Float8{}): true, reflect.TypeOf(Float16{}): true,
+	reflect.TypeOf(Double2{}): true, reflect.TypeOf(Double3{}): true, reflect.TypeOf(Double4{}): true, reflect.TypeOf(Double8{}): true, reflect.TypeOf(Double16{}): true,
+}
+
+// ValidateLayout checks that value (or element of slice/array) has the same memory layout in Go and in OpenCL C,
+// structs may contain fixed-size numbers, vector types, arrays and structs, fields must be placed at offsets
+// aligned as in OpenCL (add blank fields for padding), int, uint, bool, pointers, slices and strings are not allowed
+func ValidateLayout(value interface{}) error {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return errors.New("cl: nil has no layout")
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if _, err := clAlign(t); err != nil {
+		return fmt.Errorf("cl: %w", err)
+	}
+	return nil
+}
+
+// clAlign returns alignment of t in OpenCL C, error (without "cl:" prefix) when t has no OpenCL counterpart or different layout
+func clAlign(t reflect.Type) (int, error) {
+	if vectorTypes[t] {
+		return int(t.Size()), nil
+	}
+	switch t.Kind() {
+	case reflect.Int8, reflect.Uint8, reflect.Int16, reflect.Uint16, reflect.Int32, reflect.Uint32,
+		reflect.Int64, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return int(t.Size()), nil
+	case reflect.Array:
+		return clAlign(t.Elem())
+	case reflect.Struct:
+		offset, maxAlign := 0, 1
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			align, err := clAlign(f.Type)
+			if err != nil {
+				return 0, fmt.Errorf("field %s.%s: %w", t, f.Name, err)
+			}
+			offset = (offset + align - 1) / align * align
+			if int(f.Offset) != offset {
+				return 0, fmt.Errorf("field %s.%s is at offset %d, OpenCL places it at %d, add padding", t, f.Name, f.Offset, offset)
+			}
+			offset += int(f.Type.Size())
+			if align > maxAlign {
+				maxAlign = align
+			}
+		}
+		if size := (offset + maxAlign - 1) / maxAlign * maxAlign; size != int(t.Size()) {
+			return 0, fmt.Errorf("struct %s has size %d, OpenCL size is %d, add padding at the end", t, t.Size(), size)
+		}
+		return maxAlign, nil
+	}
+	return 0, fmt.Errorf("type %s has no fixed OpenCL layout", t)
+}
+
+var errUnsupportedValue = errors.New("cl: unsupported value")
+
+// valueArg converts Go value (fixed-size number, vector type, array or struct) to bytes of kernel argument,
+// int and uint are passed as int and uint of OpenCL (32 bits), bool as int
+func valueArg(arg interface{}) (unsafe.Pointer, int, error) {
+	switch val := arg.(type) {
+	case int:
+		if val < math.MinInt32 || val > math.MaxInt32 {
+			return nil, 0, fmt.Errorf("int %d overflows OpenCL int", val)
+		}
+		v := int32(val)
+		return unsafe.Pointer(&v), int(unsafe.Sizeof(v)), nil
+	case uint:
+		if val > math.MaxUint32 {
+			return nil, 0, fmt.Errorf("uint %d overflows OpenCL uint", val)
+		}
+		v := uint32(val)
+		return unsafe.Pointer(&v), int(unsafe.Sizeof(v)), nil
+	case bool:
+		var v int32
+		if val {
+			v = 1
+		}
+		return unsafe.Pointer(&v), int(unsafe.Sizeof(v)), nil
+	}
+	t := reflect.TypeOf(arg)
+	if t == nil {
+		return nil, 0, errUnsupportedValue
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Uint, reflect.Bool, reflect.Uintptr, reflect.Complex64, reflect.Complex128,
+		reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice,
+		reflect.String, reflect.UnsafePointer:
+		return nil, 0, errUnsupportedValue
+	}
+	if _, err := clAlign(t); err != nil {
+		return nil, 0, err
+	}
+	v := reflect.New(t)
+	v.Elem().Set(reflect.ValueOf(arg))
+	return v.UnsafePointer(), int(t.Size()), nil
+}
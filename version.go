@@ -0,0 +1,150 @@
+package highCL
+
+import (
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version parsed OpenCL version like "OpenCL 1.2 CUDA 12.0.89", "OpenCL C 2.0 " or "CL3.0"
+type Version struct {
+	Major  int
+	Minor  int
+	Vendor string // vendor specific information after the version number
+}
+
+// ParseVersion parses version text of device, platform, OpenCL C or pure.Version
+func ParseVersion(s string) (Version, error) {
+	text := strings.TrimSpace(s)
+	for _, prefix := range []string{"OpenCL C ", "OpenCL ", "CL"} {
+		if strings.HasPrefix(text, prefix) {
+			text = strings.TrimSpace(text[len(prefix):])
+			break
+		}
+	}
+	number, vendor, _ := strings.Cut(text, " ")
+	majorText, minorText, ok := strings.Cut(number, ".")
+	if !ok {
+		return Version{}, fmt.Errorf("cl: bad version %q", s)
+	}
+	major, err := strconv.Atoi(majorText)
+	if err != nil {
+		return Version{}, fmt.Errorf("cl: bad version %q", s)
+	}
+	minor, err := strconv.Atoi(minorText)
+	if err != nil {
+		return Version{}, fmt.Errorf("cl: bad version %q", s)
+	}
+	return Version{Major: major, Minor: minor, Vendor: strings.TrimSpace(vendor)}, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower, equal or greater than o, vendor part is ignored
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major < o.Major:
+		return -1
+	case v.Major > o.Major:
+		return 1
+	case v.Minor < o.Minor:
+		return -1
+	case v.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether v is major.minor or newer
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Compare(Version{Major: major, Minor: minor}) >= 0
+}
+
+// String returns "major.minor"
+func (v Version) String() string {
+	return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
+}
+
+// ExtensionSet set of extensions supported by device or platform
+type ExtensionSet map[pure.Extension]struct{}
+
+// NewExtensionSet creates set from space separated list of extensions
+func NewExtensionSet(extensions string) ExtensionSet {
+	set := ExtensionSet{}
+	for _, e := range strings.Fields(extensions) {
+		set[e] = struct{}{}
+	}
+	return set
+}
+
+// Has reports whether the extension is supported
+func (s ExtensionSet) Has(extension pure.Extension) bool {
+	_, ok := s[extension]
+	return ok
+}
+
+// HasAll reports whether all extensions are supported
+func (s ExtensionSet) HasAll(extensions ...pure.Extension) bool {
+	for _, e := range extensions {
+		if !s.Has(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Slice returns sorted extensions
+func (s ExtensionSet) Slice() []pure.Extension {
+	res := make([]pure.Extension, 0, len(s))
+	for e := range s {
+		res = append(res, e)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// ExtensionSet device info - extensions as set
+func (d *Device) ExtensionSet() (ExtensionSet, error) {
+	extensions, err := d.Extensions()
+	if err != nil {
+		return nil, err
+	}
+	return NewExtensionSet(extensions), nil
+}
+
+// ParsedVersion device info - parsed OpenCL version of the device
+func (d *Device) ParsedVersion() (Version, error) {
+	version, err := d.Version()
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(version)
+}
+
+// ParsedOpenCLCVersion device info - parsed OpenCL C version of the device compiler
+func (d *Device) ParsedOpenCLCVersion() (Version, error) {
+	version, err := d.OpenCLCVersion()
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(version)
+}
+
+// ExtensionSet platform extensions as set
+func (p *Platform) ExtensionSet() (ExtensionSet, error) {
+	extensions, err := p.getInfo(constants.CL_PLATFORM_EXTENSIONS)
+	if err != nil {
+		return nil, err
+	}
+	return NewExtensionSet(extensions), nil
+}
+
+// ParsedVersion parsed OpenCL version of the platform
+func (p *Platform) ParsedVersion() (Version, error) {
+	version, err := p.GetVersion()
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(version)
+}
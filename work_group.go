@@ -0,0 +1,172 @@
+package highCL
+
+import (
+	"fmt"
+	constants "github.com/opencl-pure/constantsCL"
+	pure "github.com/opencl-pure/pureCL"
+	"math"
+	"unsafe"
+)
+
+// WorkGroupInfo limits of the kernel on the device
+type WorkGroupInfo struct {
+	// WorkGroupSize maximal work-group size of the kernel
+	WorkGroupSize int
+	// CompileWorkGroupSize size given by __attribute__((reqd_work_group_size(X, Y, Z))), zeros when not given
+	CompileWorkGroupSize [3]int
+	// PreferredWorkGroupSizeMultiple work-group size should be its multiple for performance (warp, wavefront)
+	PreferredWorkGroupSizeMultiple int
+	// LocalMemSize local memory used by the kernel, including local arguments set so far
+	LocalMemSize uint64
+	// PrivateMemSize minimal private memory used by each work-item
+	PrivateMemSize uint64
+}
+
+// WorkGroupInfo queries limits of the kernel on the device, nil device means the device of the kernel
+func (k *Kernel) WorkGroupInfo(device *Device) (*WorkGroupInfo, error) {
+	if err := require("clGetKernelWorkGroupInfo"); err != nil {
+		return nil, err
+	}
+	if err := k.program.check(); err != nil {
+		return nil, err
+	}
+	if device == nil {
+		device = k.d
+	}
+	info := &WorkGroupInfo{}
+	var result error
+	get := func(param uint32, size uintptr, ptr unsafe.Pointer) {
+		err := pure.StatusToErr(getKernelWorkGroupInfo(k.k, device.id[0], param, pure.Size(size), ptr, nil))
+		result = pure.ErrJoin(result, err)
+	}
+	var size pure.Size
+	get(constants.CL_KERNEL_WORK_GROUP_SIZE, unsafe.Sizeof(size), unsafe.Pointer(&size))
+	info.WorkGroupSize = int(size)
+	var compile [3]pure.Size
+	get(constants.CL_KERNEL_COMPILE_WORK_GROUP_SIZE, unsafe.Sizeof(compile), unsafe.Pointer(&compile))
+	for i, s := range compile {
+		info.CompileWorkGroupSize[i] = int(s)
+	}
+	get(constants.CL_KERNEL_PREFERRED_WORK_GROUP_SIZE_MULTIPLE, unsafe.Sizeof(size), unsafe.Pointer(&size))
+	info.PreferredWorkGroupSizeMultiple = int(size)
+	get(constants.CL_KERNEL_LOCAL_MEM_SIZE, unsafe.Sizeof(info.LocalMemSize), unsafe.Pointer(&info.LocalMemSize))
+	get(constants.CL_KERNEL_PRIVATE_MEM_SIZE, unsafe.Sizeof(info.PrivateMemSize), unsafe.Pointer(&info.PrivateMemSize))
+	return info, result
+}
+
+// AutoLocal returns KernelCall which chooses local work sizes in Run from limits of the kernel and the device,
+// every local size divides its global size and the first one prefers multiples of PreferredWorkGroupSizeMultiple.
+// The limits are queried once per device, limits missing on old devices (e.g. the preferred multiple on OpenCL 1.0)
+// are ignored, local sizes are 1 when the maximal work-group size of the kernel can not be queried
+func (kc KernelCall) AutoLocal() KernelCall {
+	kc.autoLocal = true
+	return kc
+}
+
+// workGroupLimits limits of the kernel on one device cached for AutoLocal, nil info when they can not be queried
+type workGroupLimits struct {
+	info     *WorkGroupInfo
+	maxItems []int // CL_DEVICE_MAX_WORK_ITEM_SIZES
+}
+
+// autoLocal local work sizes for global work sizes on the device
+func (k *Kernel) autoLocal(device *Device, global []int) ([]int, error) {
+	limits, ok := k.workGroup[device.id[0]]
+	if !ok {
+		if err := k.program.check(); err != nil {
+			return nil, err
+		}
+		limits = &workGroupLimits{}
+		// the error is ignored, the limits queried successfully are used
+		info, _ := k.WorkGroupInfo(device)
+		if info != nil && info.WorkGroupSize > 0 {
+			limits.info = info
+			limits.maxItems, _ = device.GetInfoSizeArray(constants.CL_DEVICE_MAX_WORK_ITEM_SIZES)
+		}
+		if k.workGroup == nil {
+			k.workGroup = map[pure.Device]*workGroupLimits{}
+		}
+		k.workGroup[device.id[0]] = limits
+	}
+	if limits.info == nil {
+		local := make([]int, len(global))
+		for i := range local {
+			local[i] = 1
+		}
+		return local, nil
+	}
+	if c := limits.info.CompileWorkGroupSize; c[0] != 0 {
+		return requiredLocal(c, global)
+	}
+	return autoLocalSizes(global, limits.info.WorkGroupSize, limits.info.PreferredWorkGroupSizeMultiple, limits.maxItems), nil
+}
+
+// requiredLocal local work sizes given by reqd_work_group_size of the kernel,
+// global sizes must be their multiples and the dimensions the launch does not use must be 1
+func requiredLocal(required [3]int, global []int) ([]int, error) {
+	if len(global) == 0 || len(global) > len(required) {
+		return nil, fmt.Errorf("cl: %d dimensions of global work sizes, kernel with reqd_work_group_size needs 1 to 3", len(global))
+	}
+	for i, r := range required {
+		if i >= len(global) {
+			if r != 1 {
+				return nil, fmt.Errorf("cl: reqd_work_group_size %v of the kernel has more dimensions than global work sizes %v", required, global)
+			}
+		} else if r == 0 || global[i]%r != 0 {
+			return nil, fmt.Errorf("cl: global work sizes %v are not multiples of reqd_work_group_size %v of the kernel", global, required)
+		}
+	}
+	return required[:len(global)], nil
+}
+
+// autoLocalSizes chooses local sizes dividing global sizes, their product is at most maxWorkGroup,
+// the work-group is spread evenly over dimensions and the first dimension prefers multiples of multiple
+func autoLocalSizes(global []int, maxWorkGroup, multiple int, maxItems []int) []int {
+	if maxWorkGroup < 1 {
+		maxWorkGroup = 1
+	}
+	if multiple < 1 {
+		multiple = 1
+	}
+	local := make([]int, len(global))
+	budget := maxWorkGroup
+	for i, g := range global {
+		limit := budget
+		if i < len(maxItems) && maxItems[i] < limit {
+			limit = maxItems[i]
+		}
+		if rest := len(global) - i; rest > 1 {
+			target := int(math.Ceil(math.Pow(float64(budget), 1/float64(rest))))
+			if i == 0 && target < multiple {
+				target = multiple
+			}
+			if target < limit {
+				limit = target
+			}
+		}
+		preferred := 1
+		if i == 0 {
+			preferred = multiple
+		}
+		local[i] = largestDivisor(g, limit, preferred)
+		budget /= local[i]
+	}
+	return local
+}
+
+// largestDivisor largest divisor of n not greater than limit, multiples of multiple are preferred
+func largestDivisor(n, limit, multiple int) int {
+	best := 1
+	for l := limit; l > 1; l-- {
+		if n%l != 0 {
+			continue
+		}
+		if l%multiple == 0 {
+			return l
+		}
+		if best == 1 {
+			best = l
+		}
+	}
+	return best
+}